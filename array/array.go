@@ -1,7 +1,6 @@
 package array
 
 import (
-	"fmt"
 	"math/rand"
 )
 
@@ -14,11 +13,9 @@ func Merge[T any](ss ...[]T) []T {
 	return result
 }
 
-// Split a slice into multiple slices, which
-// The n determines the number of slice to return
-// n > 0: at most n sub slices; the last sub slice will be the unsplit remainder.
+// Chunk splits a slice into sub slices of n elements each,
+// the last sub slice holds the remainder and may be shorter.
 // n <= 0: the result is nil
-
 func Chunk[T any](s []T, n int) [][]T {
 	if n <= 0 {
 		return nil
@@ -70,16 +67,3 @@ func Column[T any, K comparable](maps []map[K]T, key K) []T {
 	}
 	return column
 }
-
-// Implode 将任意类型数组，按照分割符，转成字符串
-func Implode[T any](arr []T, delimiter string) string {
-	var result string
-	for i, v := range arr {
-		if i > 0 {
-			result += delimiter
-		}
-		result += fmt.Sprintf("%v", v)
-	}
-
-	return result
-}
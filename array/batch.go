@@ -0,0 +1,96 @@
+package array
+
+// Window returns sliding windows of size elements, advancing step elements each time.
+// Only full windows are returned; the windows share memory with s.
+// size <= 0 or step <= 0: the result is nil
+func Window[T any](s []T, size, step int) [][]T {
+	if size <= 0 || step <= 0 {
+		return nil
+	}
+
+	var windows [][]T
+	for i := 0; i+size <= len(s); i += step {
+		windows = append(windows, s[i:i+size:i+size])
+	}
+	return windows
+}
+
+// ChunkBy splits a slice at every boundary where split(prev, next) returns true,
+// prev and next being adjacent elements of s
+func ChunkBy[T any](s []T, split func(prev, next T) bool) [][]T {
+	if len(s) == 0 {
+		return nil
+	}
+
+	var chunks [][]T
+	start := 0
+	for i := 1; i < len(s); i++ {
+		if split(s[i-1], s[i]) {
+			chunks = append(chunks, s[start:i:i])
+			start = i
+		}
+	}
+	return append(chunks, s[start:])
+}
+
+// BatchByWeight groups consecutive elements into batches whose total weight does not exceed maxWeight,
+// e.g. to keep bulk inserts under a payload size limit.
+// An element heavier than maxWeight on its own is put in a batch of its own
+func BatchByWeight[T any](s []T, maxWeight int, weight func(T) int) [][]T {
+	if len(s) == 0 {
+		return nil
+	}
+
+	var (
+		batches [][]T
+		start   int
+		total   int
+	)
+	for i, v := range s {
+		w := weight(v)
+		if i > start && total+w > maxWeight {
+			batches = append(batches, s[start:i:i])
+			start, total = i, 0
+		}
+		total += w
+	}
+	return append(batches, s[start:])
+}
+
+// Page 分页信息
+type Page struct {
+	Page       int  `json:"page"`        // 当前页码，从 1 开始
+	PerPage    int  `json:"per_page"`    // 每页条数
+	Total      int  `json:"total"`       // 总条数
+	TotalPages int  `json:"total_pages"` // 总页数
+	HasPrev    bool `json:"has_prev"`    // 是否有上一页
+	HasNext    bool `json:"has_next"`    // 是否有下一页
+}
+
+// Paginate returns the elements of the given page (starting at 1) along with the page metadata.
+// page < 1 is treated as the first page, perPage <= 0 puts every element in a single page
+func Paginate[T any](s []T, page, perPage int) ([]T, Page) {
+	if page < 1 {
+		page = 1
+	}
+	if perPage <= 0 {
+		perPage = len(s)
+	}
+
+	meta := Page{Page: page, PerPage: perPage, Total: len(s)}
+	if perPage > 0 {
+		meta.TotalPages = (len(s) + perPage - 1) / perPage
+	}
+	meta.HasPrev = page > 1
+	meta.HasNext = page < meta.TotalPages
+
+	start := (page - 1) * perPage
+	if start >= len(s) {
+		return nil, meta
+	}
+	end := start + perPage
+	if end > len(s) {
+		end = len(s)
+	}
+	return s[start:end:end], meta
+}
@@ -0,0 +1,249 @@
+package array
+
+import (
+	"fmt"
+)
+
+// SetDiff 两个版本的切片之间的差异
+type SetDiff[T any] struct {
+	Added     []T // 只存在于新切片中的元素
+	Removed   []T // 只存在于旧切片中的元素
+	Unchanged []T // 两个切片中都存在的元素（取新切片中的值）
+	Moved     []T // Unchanged 中相对顺序发生了变化的元素
+}
+
+// Compare returns the elements added, removed, unchanged and moved between old and new
+func Compare[T comparable](old, new []T) SetDiff[T] {
+	return CompareBy(old, new, func(v T) T { return v })
+}
+
+// CompareBy is like Compare but identifies elements by key.
+// Duplicate keys are matched by count, so old [a a] and new [a] report one a as removed.
+// Moved holds the common elements that are not part of the longest common subsequence,
+// i.e. the smallest set of elements to move to go from the old order to the new one
+func CompareBy[T any, K comparable](old, new []T, key func(T) K) SetDiff[T] {
+	var diff SetDiff[T]
+	oldCount := make(map[K]int, len(old))
+	for _, v := range old {
+		oldCount[key(v)]++
+	}
+	newCount := make(map[K]int, len(new))
+	for _, v := range new {
+		newCount[key(v)]++
+	}
+
+	var commonOld, commonNew []T
+	for _, v := range old {
+		if k := key(v); newCount[k] > 0 {
+			newCount[k]--
+			commonOld = append(commonOld, v)
+		} else {
+			diff.Removed = append(diff.Removed, v)
+		}
+	}
+	for _, v := range new {
+		if k := key(v); oldCount[k] > 0 {
+			oldCount[k]--
+			commonNew = append(commonNew, v)
+			diff.Unchanged = append(diff.Unchanged, v)
+		} else {
+			diff.Added = append(diff.Added, v)
+		}
+	}
+
+	// 公共元素之间的插入操作即为需要移动的元素
+	for _, e := range EditScriptBy(commonOld, commonNew, key) {
+		if e.Op == EditInsert {
+			diff.Moved = append(diff.Moved, e.Value)
+		}
+	}
+	return diff
+}
+
+// EditOp 编辑操作类型
+type EditOp int
+
+const (
+	EditKeep   EditOp = iota // 保留旧切片中的元素
+	EditDelete               // 删除旧切片中的元素
+	EditInsert               // 插入新切片中的元素
+)
+
+func (op EditOp) String() string {
+	switch op {
+	case EditKeep:
+		return "keep"
+	case EditDelete:
+		return "delete"
+	case EditInsert:
+		return "insert"
+	}
+	return fmt.Sprintf("EditOp(%d)", int(op))
+}
+
+// Edit 编辑脚本中的一步操作
+type Edit[T any] struct {
+	Op       EditOp
+	OldIndex int // 旧切片中的下标，EditInsert 时为 -1
+	NewIndex int // 新切片中的下标，EditDelete 时为 -1
+	Value    T   // EditDelete 时为旧切片中的值，否则为新切片中的值
+}
+
+// EditScript returns a minimal list of keep, delete and insert operations turning old into new,
+// computed with the linear space variant of the Myers diff algorithm in O((n+m)·D) time
+func EditScript[T comparable](old, new []T) []Edit[T] {
+	return EditScriptBy(old, new, func(v T) T { return v })
+}
+
+// EditScriptBy is like EditScript but compares elements by key
+func EditScriptBy[T any, K comparable](old, new []T, key func(T) K) []Edit[T] {
+	d := &differ[T, K]{
+		old:     old,
+		new:     new,
+		oldKeys: make([]K, len(old)),
+		newKeys: make([]K, len(new)),
+	}
+	for i, v := range old {
+		d.oldKeys[i] = key(v)
+	}
+	for i, v := range new {
+		d.newKeys[i] = key(v)
+	}
+	max := (len(old)+len(new)+1)/2 + 1
+	d.offset = max + 1
+	d.forward = make([]int, 2*max+3)
+	d.backward = make([]int, 2*max+3)
+	d.diff(0, len(old), 0, len(new))
+	return d.edits
+}
+
+// differ 线性空间的 Myers 算法：找到最短编辑路径中间的一段 snake，再分别递归处理两侧，
+// 只需要 O(n+m) 的额外空间
+type differ[T any, K comparable] struct {
+	old, new          []T
+	oldKeys, newKeys  []K
+	forward, backward []int // 前向和反向搜索在每条对角线上到达的最远 x，下标加上 offset
+	offset            int
+	edits             []Edit[T]
+}
+
+// diff 生成 old[a0:a1] 到 new[b0:b1] 的编辑脚本
+func (d *differ[T, K]) diff(a0, a1, b0, b1 int) {
+	for a0 < a1 && b0 < b1 && d.oldKeys[a0] == d.newKeys[b0] {
+		d.keep(a0, b0)
+		a0++
+		b0++
+	}
+	suffix := 0
+	for a1 > a0 && b1 > b0 && d.oldKeys[a1-1] == d.newKeys[b1-1] {
+		a1--
+		b1--
+		suffix++
+	}
+
+	switch {
+	case a0 == a1:
+		for j := b0; j < b1; j++ {
+			d.edits = append(d.edits, Edit[T]{Op: EditInsert, OldIndex: -1, NewIndex: j, Value: d.new[j]})
+		}
+	case b0 == b1:
+		for i := a0; i < a1; i++ {
+			d.edits = append(d.edits, Edit[T]{Op: EditDelete, OldIndex: i, NewIndex: -1, Value: d.old[i]})
+		}
+	default:
+		// 去掉公共前后缀后两边都不为空，编辑距离至少为 2，两侧的子问题都严格更小
+		x, y, u, v := d.middleSnake(a0, a1, b0, b1)
+		d.diff(a0, x, b0, y)
+		for ; x < u; x, y = x+1, y+1 {
+			d.keep(x, y)
+		}
+		d.diff(u, a1, v, b1)
+	}
+
+	for i := 0; i < suffix; i++ {
+		d.keep(a1+i, b1+i)
+	}
+}
+
+func (d *differ[T, K]) keep(i, j int) {
+	d.edits = append(d.edits, Edit[T]{Op: EditKeep, OldIndex: i, NewIndex: j, Value: d.new[j]})
+}
+
+// middleSnake 同时从两端搜索，返回最短编辑路径中间那段 snake 的起点 (x, y) 和终点 (u, v)
+func (d *differ[T, K]) middleSnake(a0, a1, b0, b1 int) (x, y, u, v int) {
+	n, m := a1-a0, b1-b0
+	delta := n - m
+	odd := delta&1 != 0
+	off := d.offset
+	vf, vb := d.forward, d.backward
+	vf[off+1], vb[off+1] = 0, 0
+
+	for D := 0; D <= (n+m+1)/2; D++ {
+		for k := -D; k <= D; k += 2 {
+			var x int
+			if k == -D || (k != D && vf[off+k-1] < vf[off+k+1]) {
+				x = vf[off+k+1]
+			} else {
+				x = vf[off+k-1] + 1
+			}
+			y := x - k
+			x0, y0 := x, y
+			for x < n && y < m && d.oldKeys[a0+x] == d.newKeys[b0+y] {
+				x++
+				y++
+			}
+			vf[off+k] = x
+			// 反向搜索中对角线 k' 对应前向的对角线 delta-k'
+			if odd && k >= delta-(D-1) && k <= delta+(D-1) && x+vb[off+delta-k] >= n {
+				return a0 + x0, b0 + y0, a0 + x, b0 + y
+			}
+		}
+		for k := -D; k <= D; k += 2 {
+			var x int
+			if k == -D || (k != D && vb[off+k-1] < vb[off+k+1]) {
+				x = vb[off+k+1]
+			} else {
+				x = vb[off+k-1] + 1
+			}
+			y := x - k
+			x0, y0 := x, y
+			for x < n && y < m && d.oldKeys[a1-1-x] == d.newKeys[b1-1-y] {
+				x++
+				y++
+			}
+			vb[off+k] = x
+			if !odd && delta-k >= -D && delta-k <= D && x+vf[off+delta-k] >= n {
+				return a1 - x, b1 - y, a1 - x0, b1 - y0
+			}
+		}
+	}
+	// 不会到达：D 不超过 (n+m+1)/2 时两个方向的路径一定相遇
+	panic("array: diff search did not converge")
+}
+
+// ApplyEdits replays an edit script produced by EditScript on old and returns the new slice.
+// An error is returned if the script does not match old
+func ApplyEdits[T any](old []T, edits []Edit[T]) ([]T, error) {
+	result := make([]T, 0, len(old))
+	pos := 0
+	for i, e := range edits {
+		switch e.Op {
+		case EditKeep, EditDelete:
+			if e.OldIndex != pos || pos >= len(old) {
+				return nil, fmt.Errorf("array: edit %d: %s at old index %d, expected %d", i, e.Op, e.OldIndex, pos)
+			}
+			pos++
+			if e.Op == EditKeep {
+				result = append(result, e.Value)
+			}
+		case EditInsert:
+			result = append(result, e.Value)
+		default:
+			return nil, fmt.Errorf("array: edit %d: unknown operation %s", i, e.Op)
+		}
+	}
+	if pos != len(old) {
+		return nil, fmt.Errorf("array: edit script covers %d of %d old elements", pos, len(old))
+	}
+	return result, nil
+}
@@ -0,0 +1,162 @@
+package array
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// lcsLen 动态规划计算最长公共子序列的长度，用于校验编辑脚本是否最短
+func lcsLen(a, b []int) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for i := range a {
+		for j := range b {
+			switch {
+			case a[i] == b[j]:
+				cur[j+1] = prev[j] + 1
+			case prev[j+1] > cur[j]:
+				cur[j+1] = prev[j+1]
+			default:
+				cur[j+1] = cur[j]
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func randomInts(r *rand.Rand, n, alphabet int) []int {
+	s := make([]int, n)
+	for i := range s {
+		s[i] = r.Intn(alphabet)
+	}
+	return s
+}
+
+func TestEditScriptRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 500; i++ {
+		old := randomInts(r, r.Intn(40), 1+r.Intn(6))
+		new := randomInts(r, r.Intn(40), 1+r.Intn(6))
+		edits := EditScript(old, new)
+
+		got, err := ApplyEdits(old, edits)
+		if err != nil {
+			t.Fatalf("ApplyEdits(%v, %v): %v", old, new, err)
+		}
+		if len(got) != len(new) {
+			t.Fatalf("ApplyEdits(%v): got %v, want %v", old, got, new)
+		}
+		for j := range got {
+			if got[j] != new[j] {
+				t.Fatalf("ApplyEdits(%v): got %v, want %v", old, got, new)
+			}
+		}
+
+		keeps := 0
+		for _, e := range edits {
+			if e.Op == EditKeep {
+				if old[e.OldIndex] != new[e.NewIndex] {
+					t.Fatalf("keep of different elements %d and %d", old[e.OldIndex], new[e.NewIndex])
+				}
+				keeps++
+			}
+		}
+		if want := lcsLen(old, new); keeps != want {
+			t.Fatalf("EditScript(%v, %v) keeps %d elements, the LCS has %d", old, new, keeps, want)
+		}
+		if len(edits) != len(old)+len(new)-keeps {
+			t.Fatalf("edit script has %d operations, want %d", len(edits), len(old)+len(new)-keeps)
+		}
+	}
+}
+
+func TestEditScriptDisjointMemory(t *testing.T) {
+	const n = 3000
+	old := make([]int, n)
+	new := make([]int, n)
+	for i := range old {
+		old[i] = i
+		new[i] = n + i
+	}
+	allocs := testing.AllocsPerRun(1, func() {
+		if edits := EditScript(old, new); len(edits) != 2*n {
+			t.Fatalf("got %d edits, want %d", len(edits), 2*n)
+		}
+	})
+	// 线性空间算法的分配次数只与输出的增长有关
+	if allocs > 100 {
+		t.Fatalf("%v allocations for disjoint slices", allocs)
+	}
+}
+
+func TestApplyEditsMismatch(t *testing.T) {
+	edits := EditScript([]int{1, 2, 3}, []int{1, 3})
+	if _, err := ApplyEdits([]int{1, 2}, edits); err == nil {
+		t.Fatal("ApplyEdits should reject a script for a different slice")
+	}
+}
+
+func TestCompareDuplicates(t *testing.T) {
+	diff := Compare([]string{"a", "a", "b"}, []string{"b", "a", "c"})
+	if len(diff.Removed) != 1 || diff.Removed[0] != "a" {
+		t.Fatalf("Removed: got %v, want [a]", diff.Removed)
+	}
+	if len(diff.Added) != 1 || diff.Added[0] != "c" {
+		t.Fatalf("Added: got %v, want [c]", diff.Added)
+	}
+	if len(diff.Unchanged) != 2 || len(diff.Moved) != 1 {
+		t.Fatalf("Unchanged %v, Moved %v", diff.Unchanged, diff.Moved)
+	}
+}
+
+func TestEditScriptByIndexes(t *testing.T) {
+	type item struct {
+		id      int
+		version int
+	}
+	old := []item{{1, 0}, {2, 0}, {3, 0}}
+	new := []item{{2, 1}, {4, 1}, {3, 1}}
+	edits := EditScriptBy(old, new, func(v item) int { return v.id })
+
+	oldPos, newPos := 0, 0
+	for _, e := range edits {
+		switch e.Op {
+		case EditKeep:
+			if e.OldIndex != oldPos || e.NewIndex != newPos || e.Value != new[newPos] {
+				t.Fatalf("keep %+v at old %d new %d, the value should come from new", e, oldPos, newPos)
+			}
+			oldPos++
+			newPos++
+		case EditDelete:
+			if e.OldIndex != oldPos || e.NewIndex != -1 || e.Value != old[oldPos] {
+				t.Fatalf("delete %+v at old %d", e, oldPos)
+			}
+			oldPos++
+		case EditInsert:
+			if e.OldIndex != -1 || e.NewIndex != newPos || e.Value != new[newPos] {
+				t.Fatalf("insert %+v at new %d", e, newPos)
+			}
+			newPos++
+		}
+	}
+	if oldPos != len(old) || newPos != len(new) || len(edits) != 4 {
+		t.Fatalf("edits %v do not cover both slices minimally", edits)
+	}
+	if got, err := ApplyEdits(old, edits); err != nil || len(got) != 3 || got[2] != new[2] {
+		t.Fatalf("ApplyEdits: got %v %v, want %v", got, err, new)
+	}
+}
+
+func TestCompareMoved(t *testing.T) {
+	diff := Compare([]int{1, 2, 3, 4}, []int{4, 1, 2, 3})
+	if len(diff.Moved) != 1 || diff.Moved[0] != 4 {
+		t.Fatalf("Moved: got %v, want [4]", diff.Moved)
+	}
+	if len(diff.Added) != 0 || len(diff.Removed) != 0 || len(diff.Unchanged) != 4 {
+		t.Fatalf("unexpected diff %+v", diff)
+	}
+	if diff := Compare([]int(nil), nil); len(diff.Unchanged)+len(diff.Added)+len(diff.Removed) != 0 {
+		t.Fatalf("diff of empty slices: %+v", diff)
+	}
+}
@@ -0,0 +1,239 @@
+package array
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Implode 将任意类型数组，按照分割符，转成字符串
+// 字符串、整数、浮点数和布尔值直接格式化，其他类型使用 fmt 的 %v 格式
+func Implode[T any](arr []T, delimiter string) string {
+	switch a := any(arr).(type) {
+	case []string:
+		return strings.Join(a, delimiter)
+	case []int:
+		return implodeInts(a, delimiter)
+	case []int64:
+		return implodeInts(a, delimiter)
+	case []int32:
+		return implodeInts(a, delimiter)
+	case []uint:
+		return implodeUints(a, delimiter)
+	case []uint64:
+		return implodeUints(a, delimiter)
+	case []uint32:
+		return implodeUints(a, delimiter)
+	case []float64:
+		return implodeFloats(a, delimiter, 64)
+	case []float32:
+		return implodeFloats(a, delimiter, 32)
+	case []bool:
+		return implodeBools(a, delimiter)
+	}
+
+	var (
+		b       strings.Builder
+		scratch [64]byte
+	)
+	for i, v := range arr {
+		if i > 0 {
+			b.WriteString(delimiter)
+		}
+		b.Write(appendValue(scratch[:0], v))
+	}
+	return b.String()
+}
+
+// ImplodeFunc 使用 format 格式化每个元素，再按照分割符拼接成字符串
+func ImplodeFunc[T any](arr []T, delimiter string, format func(T) string) string {
+	var b strings.Builder
+	for i, v := range arr {
+		if i > 0 {
+			b.WriteString(delimiter)
+		}
+		b.WriteString(format(v))
+	}
+	return b.String()
+}
+
+func implodeInts[T int | int32 | int64](arr []T, delimiter string) string {
+	var b strings.Builder
+	buf := make([]byte, 0, 20)
+	b.Grow(len(arr) * (8 + len(delimiter)))
+	for i, v := range arr {
+		if i > 0 {
+			b.WriteString(delimiter)
+		}
+		b.Write(strconv.AppendInt(buf[:0], int64(v), 10))
+	}
+	return b.String()
+}
+
+func implodeUints[T uint | uint32 | uint64](arr []T, delimiter string) string {
+	var b strings.Builder
+	buf := make([]byte, 0, 20)
+	b.Grow(len(arr) * (8 + len(delimiter)))
+	for i, v := range arr {
+		if i > 0 {
+			b.WriteString(delimiter)
+		}
+		b.Write(strconv.AppendUint(buf[:0], uint64(v), 10))
+	}
+	return b.String()
+}
+
+// implodeFloats bitSize 为元素的位数，保证 float32 输出最短的精确表示
+func implodeFloats[T float32 | float64](arr []T, delimiter string, bitSize int) string {
+	var b strings.Builder
+	buf := make([]byte, 0, 24)
+	b.Grow(len(arr) * (8 + len(delimiter)))
+	for i, v := range arr {
+		if i > 0 {
+			b.WriteString(delimiter)
+		}
+		b.Write(strconv.AppendFloat(buf[:0], float64(v), 'g', -1, bitSize))
+	}
+	return b.String()
+}
+
+func implodeBools(arr []bool, delimiter string) string {
+	var b strings.Builder
+	b.Grow(len(arr) * (5 + len(delimiter)))
+	for i, v := range arr {
+		if i > 0 {
+			b.WriteString(delimiter)
+		}
+		b.WriteString(strconv.FormatBool(v))
+	}
+	return b.String()
+}
+
+// appendValue 格式化单个元素，基础类型（包括以其为底层类型的自定义类型）不经过 fmt
+func appendValue(buf []byte, v any) []byte {
+	switch x := v.(type) {
+	case string:
+		return append(buf, x...)
+	case int:
+		return strconv.AppendInt(buf, int64(x), 10)
+	case int64:
+		return strconv.AppendInt(buf, x, 10)
+	case uint64:
+		return strconv.AppendUint(buf, x, 10)
+	case float64:
+		return strconv.AppendFloat(buf, x, 'g', -1, 64)
+	case float32:
+		return strconv.AppendFloat(buf, float64(x), 'g', -1, 32)
+	case bool:
+		return strconv.AppendBool(buf, x)
+	case fmt.Stringer, error:
+		return fmt.Append(buf, x)
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.String:
+		return append(buf, rv.String()...)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.AppendInt(buf, rv.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.AppendUint(buf, rv.Uint(), 10)
+	case reflect.Float32:
+		return strconv.AppendFloat(buf, rv.Float(), 'g', -1, 32)
+	case reflect.Float64:
+		return strconv.AppendFloat(buf, rv.Float(), 'g', -1, 64)
+	case reflect.Bool:
+		return strconv.AppendBool(buf, rv.Bool())
+	}
+	return fmt.Append(buf, v)
+}
+
+// Explodable Explode 支持解析的元素类型
+type Explodable interface {
+	~string | ~bool |
+		~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 |
+		~float32 | ~float64
+}
+
+// ExplodeError 记录解析失败的元素
+type ExplodeError struct {
+	Index int    // 元素在分割结果中的下标
+	Value string // 去除首尾空白后的原始值
+	Err   error
+}
+
+func (e *ExplodeError) Error() string {
+	return fmt.Sprintf("array: element %d %q: %v", e.Index, e.Value, e.Err)
+}
+
+func (e *ExplodeError) Unwrap() error {
+	return e.Err
+}
+
+// Explode 按照分割符拆分字符串，并将每一部分解析为 T，是 Implode 的逆操作
+// 每一部分都会去除首尾空白，空的部分会被忽略，例如 "1, 2,,3," 解析为 []int{1, 2, 3}
+// 解析失败的元素以 *ExplodeError 的形式合并返回，成功的元素仍然保留在结果中
+func Explode[T Explodable](s, delimiter string) ([]T, error) {
+	return ExplodeFunc(s, delimiter, parseValue[T])
+}
+
+// ExplodeFunc 与 Explode 相同，但使用 parse 解析每一部分
+func ExplodeFunc[T any](s, delimiter string, parse func(string) (T, error)) ([]T, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(s, delimiter)
+	result := make([]T, 0, len(parts))
+	var errs []error
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		v, err := parse(part)
+		if err != nil {
+			errs = append(errs, &ExplodeError{Index: i, Value: part, Err: err})
+			continue
+		}
+		result = append(result, v)
+	}
+	return result, errors.Join(errs...)
+}
+
+func parseValue[T Explodable](s string) (T, error) {
+	var v T
+	rv := reflect.ValueOf(&v).Elem()
+	switch rv.Kind() {
+	case reflect.String:
+		rv.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return v, err
+		}
+		rv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, rv.Type().Bits())
+		if err != nil {
+			return v, err
+		}
+		rv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, rv.Type().Bits())
+		if err != nil {
+			return v, err
+		}
+		rv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, rv.Type().Bits())
+		if err != nil {
+			return v, err
+		}
+		rv.SetFloat(f)
+	}
+	return v, nil
+}
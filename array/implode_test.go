@@ -0,0 +1,29 @@
+package array
+
+import "testing"
+
+type celsius float64
+
+func TestImplode(t *testing.T) {
+	tests := []struct {
+		got, want string
+	}{
+		{Implode([]float64{1.5, -2, 1e21}, ","), "1.5,-2,1e+21"},
+		// float32 按 32 位输出最短表示，而不是转换成 float64 之后的值
+		{Implode([]float32{0.1, 3}, ","), "0.1,3"},
+		{Implode([]bool{true, false}, " | "), "true | false"},
+		{Implode([]celsius{36.6, 0.1}, ","), "36.6,0.1"},
+		{Implode([]int64{-1, 2}, ","), "-1,2"},
+		{Implode([]bool{}, ","), ""},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Fatalf("Implode: got %q, want %q", tt.got, tt.want)
+		}
+	}
+
+	floats, err := Explode[float32](Implode([]float32{0.1, 2.5}, ","), ",")
+	if err != nil || len(floats) != 2 || floats[0] != 0.1 || floats[1] != 2.5 {
+		t.Fatalf("Explode(Implode): got %v %v", floats, err)
+	}
+}
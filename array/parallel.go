@@ -0,0 +1,171 @@
+package array
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"sync"
+)
+
+// PanicError 并发任务中发生的 panic，会被捕获并以错误的形式返回
+type PanicError struct {
+	Index int    // 发生 panic 的元素下标
+	Value any    // recover 得到的值
+	Stack []byte // panic 时的调用栈
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("array: panic at index %d: %v", e.Index, e.Value)
+}
+
+// IndexError 记录出错元素的下标，可通过 errors.As 获取
+type IndexError struct {
+	Index int
+	Err   error
+}
+
+func (e *IndexError) Error() string {
+	return fmt.Sprintf("array: index %d: %v", e.Index, e.Err)
+}
+
+func (e *IndexError) Unwrap() error {
+	return e.Err
+}
+
+type parallelConfig struct {
+	limit      int
+	collectAll bool
+}
+
+// ParallelOption configures ParallelMap, ParallelFilter and ParallelForEach
+type ParallelOption func(*parallelConfig)
+
+// WithLimit sets the maximum number of concurrent workers, n <= 0 means runtime.GOMAXPROCS(0)
+func WithLimit(n int) ParallelOption {
+	return func(c *parallelConfig) {
+		c.limit = n
+	}
+}
+
+// WithCollectErrors keeps processing after a failure and returns all errors joined,
+// by default the first error cancels the remaining work
+func WithCollectErrors() ParallelOption {
+	return func(c *parallelConfig) {
+		c.collectAll = true
+	}
+}
+
+// ParallelMap applies fn to every element of s using a bounded number of goroutines,
+// results are returned in input order.
+// Every error is wrapped in an *IndexError, panics are returned as *PanicError
+func ParallelMap[T, U any](ctx context.Context, s []T, fn func(ctx context.Context, v T) (U, error), opts ...ParallelOption) ([]U, error) {
+	result := make([]U, len(s))
+	err := parallelRun(ctx, len(s), func(ctx context.Context, i int) error {
+		u, err := fn(ctx, s[i])
+		if err != nil {
+			return err
+		}
+		result[i] = u
+		return nil
+	}, opts)
+	return result, err
+}
+
+// ParallelFilter keeps the elements for which fn returns true, preserving input order
+func ParallelFilter[T any](ctx context.Context, s []T, fn func(ctx context.Context, v T) (bool, error), opts ...ParallelOption) ([]T, error) {
+	keep, err := ParallelMap(ctx, s, fn, opts...)
+	var result []T
+	for i, ok := range keep {
+		if ok {
+			result = append(result, s[i])
+		}
+	}
+	return result, err
+}
+
+// ParallelForEach calls fn for every element of s using a bounded number of goroutines
+func ParallelForEach[T any](ctx context.Context, s []T, fn func(ctx context.Context, v T) error, opts ...ParallelOption) error {
+	return parallelRun(ctx, len(s), func(ctx context.Context, i int) error {
+		return fn(ctx, s[i])
+	}, opts)
+}
+
+// parallelRun 使用固定数量的协程处理 [0, n) 的下标
+func parallelRun(ctx context.Context, n int, fn func(ctx context.Context, i int) error, opts []ParallelOption) error {
+	cfg := parallelConfig{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.limit <= 0 {
+		cfg.limit = runtime.GOMAXPROCS(0)
+	}
+	if cfg.limit > n {
+		cfg.limit = n
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		errs     []error
+		firstErr error
+	)
+	indexes := make(chan int)
+	for w := 0; w < cfg.limit; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				err := safeCall(ctx, i, fn)
+				if err == nil {
+					continue
+				}
+				mu.Lock()
+				if cfg.collectAll {
+					errs = append(errs, err)
+				} else if firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+FEED:
+	for i := 0; i < n; i++ {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			break FEED
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	// 外部 context 被取消时，部分元素未被处理
+	return ctx.Err()
+}
+
+// safeCall 调用 fn 并把 panic 转换为错误
+func safeCall(ctx context.Context, i int, fn func(ctx context.Context, i int) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Index: i, Value: r, Stack: debug.Stack()}
+		}
+	}()
+	if err = fn(ctx, i); err != nil {
+		err = &IndexError{Index: i, Err: err}
+	}
+	return
+}
@@ -0,0 +1,124 @@
+package array
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParallelMapOrder(t *testing.T) {
+	s := make([]int, 100)
+	for i := range s {
+		s[i] = i
+	}
+	var running, maxRunning atomic.Int32
+	result, err := ParallelMap(context.Background(), s, func(ctx context.Context, v int) (int, error) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			max := maxRunning.Load()
+			if n <= max || maxRunning.CompareAndSwap(max, n) {
+				break
+			}
+		}
+		// 让后面的元素先完成，结果仍按输入顺序返回
+		time.Sleep(time.Duration(len(s)-v) * 10 * time.Microsecond)
+		return v * 2, nil
+	}, WithLimit(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, v := range result {
+		if v != i*2 {
+			t.Fatalf("result[%d]: got %d, want %d", i, v, i*2)
+		}
+	}
+	if max := maxRunning.Load(); max > 4 {
+		t.Fatalf("%d calls ran concurrently with a limit of 4", max)
+	}
+
+	kept, _ := ParallelFilter(context.Background(), s, func(ctx context.Context, v int) (bool, error) {
+		return v%10 == 0, nil
+	})
+	if len(kept) != 10 || kept[3] != 30 {
+		t.Fatalf("ParallelFilter: got %v", kept)
+	}
+}
+
+func TestParallelPanic(t *testing.T) {
+	err := ParallelForEach(context.Background(), []int{0, 1, 2}, func(ctx context.Context, v int) error {
+		if v == 1 {
+			panic("boom")
+		}
+		return nil
+	})
+	var panicErr *PanicError
+	if !errors.As(err, &panicErr) {
+		t.Fatalf("got %v, want a *PanicError", err)
+	}
+	if panicErr.Index != 1 || panicErr.Value != "boom" || len(panicErr.Stack) == 0 {
+		t.Fatalf("unexpected panic error %+v", panicErr)
+	}
+}
+
+func TestParallelFirstErrorCancels(t *testing.T) {
+	errFailed := errors.New("failed")
+	s := make([]int, 1000)
+	var calls atomic.Int32
+	err := ParallelForEach(context.Background(), s, func(ctx context.Context, v int) error {
+		if calls.Add(1) == 1 {
+			return errFailed
+		}
+		<-ctx.Done()
+		return ctx.Err()
+	}, WithLimit(2))
+
+	var indexErr *IndexError
+	if !errors.As(err, &indexErr) || !errors.Is(err, errFailed) {
+		t.Fatalf("got %v, want the first error wrapped in an *IndexError", err)
+	}
+	// 第一个错误取消了剩余的元素
+	if n := calls.Load(); n >= int32(len(s)) {
+		t.Fatalf("all %d elements were processed after the first error", n)
+	}
+}
+
+func TestParallelCollectErrors(t *testing.T) {
+	s := []int{0, 1, 2, 3, 4, 5}
+	var calls atomic.Int32
+	err := ParallelForEach(context.Background(), s, func(ctx context.Context, v int) error {
+		calls.Add(1)
+		if v%2 == 1 {
+			return errors.New("odd")
+		}
+		return nil
+	}, WithCollectErrors())
+
+	if calls.Load() != int32(len(s)) {
+		t.Fatalf("processed %d elements, want %d", calls.Load(), len(s))
+	}
+	indexes := make(map[int]bool)
+	for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var indexErr *IndexError
+		if !errors.As(e, &indexErr) {
+			t.Fatalf("error %v is not an *IndexError", e)
+		}
+		indexes[indexErr.Index] = true
+	}
+	if len(indexes) != 3 || !indexes[1] || !indexes[3] || !indexes[5] {
+		t.Fatalf("errors for indexes %v, want 1, 3 and 5", indexes)
+	}
+}
+
+func TestParallelContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := ParallelForEach(ctx, make([]int, 10), func(ctx context.Context, v int) error {
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want context.Canceled", err)
+	}
+}
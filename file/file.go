@@ -13,6 +13,8 @@ import (
 	"strings"
 	"sync"
 	"syscall"
+
+	"github.com/DavidYou21st/go-tools/stream"
 )
 
 //关于大文件的操作，为了避免一次性将整个文件加载到内存中造成内存溢出，我们需要将大文件切片成多个小的文件片段来操作。
@@ -169,6 +171,31 @@ func MergeFile(dirname, filename string) {
 	wg.Wait()
 }
 
+// Lines 按行惰性读取文件，适合处理无法一次性加载到内存的大文件
+// 返回的 io.Closer 用于在读取结束后关闭文件，读取过程中的错误通过 Stream.Err 获取
+// 单行最长为 bufio.MaxScanTokenSize（64KB），超出时读取停止并返回 bufio.ErrTooLong，更长的行使用 LinesWithMaxSize
+func Lines(filePath string) (stream.Stream[string], io.Closer, error) {
+	return LinesWithMaxSize(filePath, bufio.MaxScanTokenSize)
+}
+
+// LinesWithMaxSize 与 Lines 相同，但单行最长为 maxLineSize 字节，小于等于 0 时使用 bufio.MaxScanTokenSize
+func LinesWithMaxSize(filePath string, maxLineSize int) (stream.Stream[string], io.Closer, error) {
+	if maxLineSize <= 0 {
+		maxLineSize = bufio.MaxScanTokenSize
+	}
+	fd, err := os.Open(filePath)
+	if err != nil {
+		return stream.Empty[string](), nil, err
+	}
+	sc := bufio.NewScanner(fd)
+	initial := 4096
+	if maxLineSize < initial {
+		initial = maxLineSize
+	}
+	sc.Buffer(make([]byte, 0, initial), maxLineSize)
+	return stream.FromScanner(sc), fd, nil
+}
+
 // CopyFile 拷贝文件，将源文件srcFileName的内容拷贝到目标文件dstFileName
 func CopyFile(dstFileName string, srcFileName string) (written int64, err error) {
 	srcFile, err := os.Open(srcFileName)
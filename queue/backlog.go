@@ -0,0 +1,197 @@
+package queue
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Backlog 的适配器，让没有阻塞接口或方法签名不同的队列也可以作为 Backlog 使用
+// BlockingQueue、BlockingPriorityQueue 和 FairQueue 直接实现了 Backlog
+
+var (
+	_ Backlog[int]    = (*containerBacklog[int])(nil)
+	_ Backlog[int]    = (*delayBacklog[int])(nil)
+	_ Backlog[[]byte] = diskBacklog{}
+)
+
+// containerBacklog 把非阻塞的队列包装为 Backlog，队列满时 Put 等待，队列空时 Take 等待
+type containerBacklog[T any] struct {
+	mutex    sync.Mutex
+	push     func(v T) error // push 和 pop 在持有锁时调用，队列满或空时分别返回 ErrFull 和 ErrEmpty
+	pop      func() (T, error)
+	len      func() int
+	notEmpty signal
+	notFull  signal
+	closed   bool
+}
+
+// Backlog returns q as a Backlog, q must not be used directly afterwards
+func (q *LoopQueue[T]) Backlog() Backlog[T] {
+	return &containerBacklog[T]{push: pushUnlessFull(q.IsFull, q.Push), pop: q.Pop, len: q.Len}
+}
+
+// Backlog returns q as a Backlog, q must not be used directly afterwards
+// since its Push and Pop do not wake up the waiting callers of the backlog
+func (q *SyncLoopQueue[T]) Backlog() Backlog[T] {
+	return &containerBacklog[T]{push: pushUnlessFull(q.IsFull, q.Push), pop: q.Pop, len: q.Len}
+}
+
+// pushUnlessFull 队列满时不调用 push，避免 Put 等待空位期间 hook 收到 OnDrop
+func pushUnlessFull[T any](isFull func() bool, push func(v T) error) func(v T) error {
+	return func(v T) error {
+		if isFull() {
+			return ErrFull
+		}
+		return push(v)
+	}
+}
+
+// Backlog returns d as a FIFO Backlog adding at the back and taking from the front,
+// d must not be used directly afterwards
+func (d *Deque[T]) Backlog() Backlog[T] {
+	return &containerBacklog[T]{
+		push: func(v T) error {
+			d.PushBack(v)
+			return nil
+		},
+		pop: d.PopFront,
+		len: d.Len,
+	}
+}
+
+func (b *containerBacklog[T]) Put(ctx context.Context, v T) error {
+	for {
+		b.mutex.Lock()
+		if b.closed {
+			b.mutex.Unlock()
+			return ErrClosed
+		}
+		err := b.push(v)
+		if err == nil {
+			b.notEmpty.broadcast()
+		}
+		if err != ErrFull {
+			b.mutex.Unlock()
+			return err
+		}
+		wait := b.notFull.wait()
+		b.mutex.Unlock()
+
+		select {
+		case <-wait:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Take removes the oldest element, waiting for one until ctx is done.
+// Once the backlog is closed the remaining elements can still be taken, then ErrClosed is returned
+func (b *containerBacklog[T]) Take(ctx context.Context) (T, error) {
+	var zero T
+	for {
+		b.mutex.Lock()
+		v, err := b.pop()
+		if err == nil {
+			b.notFull.broadcast()
+		}
+		if err != ErrEmpty {
+			b.mutex.Unlock()
+			return v, err
+		}
+		if b.closed {
+			b.mutex.Unlock()
+			return zero, ErrClosed
+		}
+		wait := b.notEmpty.wait()
+		b.mutex.Unlock()
+
+		select {
+		case <-wait:
+		case <-ctx.Done():
+			return zero, ctx.Err()
+		}
+	}
+}
+
+func (b *containerBacklog[T]) Len() int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.len()
+}
+
+func (b *containerBacklog[T]) Close() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.closed {
+		return
+	}
+	b.closed = true
+	b.notEmpty.broadcast()
+	b.notFull.broadcast()
+}
+
+// delayBacklog 延迟队列的 Backlog 适配器
+type delayBacklog[T any] struct {
+	queue *DelayQueue[T]
+	delay func(v T) time.Duration
+}
+
+// Backlog returns q as a Backlog where each element becomes available after delay(v),
+// a nil delay makes elements available immediately. Once the backlog is closed
+// the pending elements can still be taken without waiting for their due time
+func (q *DelayQueue[T]) Backlog(delay func(v T) time.Duration) Backlog[T] {
+	if delay == nil {
+		delay = func(T) time.Duration { return 0 }
+	}
+	return &delayBacklog[T]{queue: q, delay: delay}
+}
+
+func (b *delayBacklog[T]) Put(_ context.Context, v T) error {
+	return b.queue.Put("", v, b.delay(v))
+}
+
+func (b *delayBacklog[T]) Take(ctx context.Context) (T, error) {
+	v, err := b.queue.Take(ctx)
+	if err == ErrClosed {
+		return b.queue.takeClosed()
+	}
+	return v, err
+}
+
+func (b *delayBacklog[T]) Len() int {
+	return b.queue.Len()
+}
+
+func (b *delayBacklog[T]) Close() {
+	b.queue.Close()
+}
+
+// diskBacklog 持久化队列的 Backlog 适配器
+type diskBacklog struct {
+	queue *DiskQueue
+}
+
+// Backlog returns q as a Backlog. Put does not wait, closing the backlog closes q
+// and discards the error of Close, call Sync first to check that the records were flushed.
+// Records not taken before Close stay on disk for the next OpenDiskQueue
+func (q *DiskQueue) Backlog() Backlog[[]byte] {
+	return diskBacklog{queue: q}
+}
+
+func (b diskBacklog) Put(_ context.Context, data []byte) error {
+	return b.queue.Put(data)
+}
+
+func (b diskBacklog) Take(ctx context.Context) ([]byte, error) {
+	return b.queue.Take(ctx)
+}
+
+func (b diskBacklog) Len() int {
+	return b.queue.Len()
+}
+
+func (b diskBacklog) Close() {
+	_ = b.queue.Close()
+}
@@ -0,0 +1,126 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBacklogAdapters(t *testing.T) {
+	tests := map[string]func() Backlog[int]{
+		"LoopQueue": func() Backlog[int] {
+			return NewLoopQueue[int](2).Backlog()
+		},
+		"SyncLoopQueue": func() Backlog[int] {
+			return NewSyncLoopQueue(NewLoopQueue[int](2)).Backlog()
+		},
+		"Deque": func() Backlog[int] {
+			return NewDeque[int](2).Backlog()
+		},
+		"DelayQueue": func() Backlog[int] {
+			return NewDelayQueue[int]().Backlog(nil)
+		},
+	}
+	for name, newBacklog := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			b := newBacklog()
+
+			result := make(chan int, 1)
+			go func() {
+				v, _ := b.Take(ctx)
+				result <- v
+			}()
+			time.Sleep(10 * time.Millisecond)
+			if err := b.Put(ctx, 1); err != nil {
+				t.Fatal(err)
+			}
+			if v := <-result; v != 1 {
+				t.Fatalf("Take: got %d, want 1", v)
+			}
+
+			_ = b.Put(ctx, 2)
+			_ = b.Put(ctx, 3)
+			if b.Len() != 2 {
+				t.Fatalf("Len: got %d, want 2", b.Len())
+			}
+			b.Close()
+			if err := b.Put(ctx, 4); err != ErrClosed {
+				t.Fatalf("Put after Close: got %v, want ErrClosed", err)
+			}
+			// 关闭后仍可以取出剩余的元素
+			for _, want := range []int{2, 3} {
+				if v, err := b.Take(ctx); v != want || err != nil {
+					t.Fatalf("Take after Close: got %d %v, want %d", v, err, want)
+				}
+			}
+			if _, err := b.Take(ctx); err != ErrClosed {
+				t.Fatalf("Take on a closed empty backlog: got %v, want ErrClosed", err)
+			}
+		})
+	}
+}
+
+func TestLoopQueueBacklogWaitsForSpace(t *testing.T) {
+	ctx := context.Background()
+	hook := &MetricsHook[int]{}
+	q := NewLoopQueue[int](1)
+	q.SetHook(hook)
+	b := q.Backlog()
+	_ = b.Put(ctx, 1)
+
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := b.Put(timeout, 2); err != context.DeadlineExceeded {
+		t.Fatalf("Put on a full backlog: got %v, want DeadlineExceeded", err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- b.Put(ctx, 3) }()
+	time.Sleep(10 * time.Millisecond)
+	if v, _ := b.Take(ctx); v != 1 {
+		t.Fatalf("Take: got %d, want 1", v)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	// 等待空位期间不会报告 OnDrop
+	if m := hook.Metrics(); m.Dropped != 0 || m.Enqueued != 2 {
+		t.Fatalf("unexpected metrics %+v", m)
+	}
+}
+
+func TestDelayQueueBacklogPool(t *testing.T) {
+	q := NewDelayQueue[*Job]()
+	p := NewPool(PoolOptions{Backlog: q.Backlog(func(j *Job) time.Duration {
+		return time.Duration(j.Priority) * time.Millisecond
+	})})
+	defer p.ShutdownNow()
+
+	start := time.Now()
+	f, _ := SubmitFunc(context.Background(), p, 20, func(ctx context.Context) (time.Duration, error) {
+		return time.Since(start), nil
+	})
+	if elapsed, _ := f.Wait(context.Background()); elapsed < 20*time.Millisecond {
+		t.Fatalf("the job ran after %v, before its delay", elapsed)
+	}
+}
+
+func TestDiskQueueBacklog(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	b := openTestDiskQueue(t, dir, DiskQueueOptions{}).Backlog()
+	_ = b.Put(ctx, []byte("first"))
+	_ = b.Put(ctx, []byte("second"))
+	if data, err := b.Take(ctx); string(data) != "first" || err != nil {
+		t.Fatalf("Take: got %q %v, want first", data, err)
+	}
+	b.Close()
+	if err := b.Put(ctx, []byte("third")); err != ErrClosed {
+		t.Fatalf("Put after Close: got %v, want ErrClosed", err)
+	}
+
+	// 关闭时未取出的记录保留在磁盘上
+	q := openTestDiskQueue(t, dir, DiskQueueOptions{})
+	defer q.Close()
+	expectTake(t, q, "second")
+}
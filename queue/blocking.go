@@ -0,0 +1,227 @@
+package queue
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// 阻塞队列，队列满时生产者等待空位，队列空时消费者等待元素
+
+type BlockingQueue[T any] struct {
+	mutex    sync.Mutex
+	items    *LoopQueue[T]
+	notEmpty signal
+	notFull  signal
+	closed   bool
+
+	hook     Hook[T]
+	enqueued *LoopQueue[time.Time] // 设置 hook 时记录每个元素的入队时间，用于计算等待时间
+}
+
+// NewBlockingQueue returns a queue holding at most size elements, size <= 0 means unbounded
+func NewBlockingQueue[T any](size int) *BlockingQueue[T] {
+	q := &BlockingQueue[T]{}
+	if size > 0 {
+		q.items = NewLoopQueue[T](size)
+	} else {
+		q.items = NewGrowableLoopQueue[T](minGrowCap)
+	}
+	return q
+}
+
+// NewBlockingQueueWithHook returns a queue like NewBlockingQueue that reports its events to hook
+func NewBlockingQueueWithHook[T any](size int, hook Hook[T]) *BlockingQueue[T] {
+	q := NewBlockingQueue[T](size)
+	q.SetHook(hook)
+	return q
+}
+
+// SetHook reports the events of the queue to hook, a nil hook removes it.
+// Elements already in the queue are considered enqueued now
+func (q *BlockingQueue[T]) SetHook(hook Hook[T]) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.hook = hook
+	q.enqueued = nil
+	if hook != nil {
+		q.enqueued = newEnqueueTimes(q.items.Len())
+	}
+}
+
+// Len returns the number of elements in the queue
+func (q *BlockingQueue[T]) Len() int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return q.items.Len()
+}
+
+// Cap returns the capacity of the queue, 0 for an unbounded queue
+func (q *BlockingQueue[T]) Cap() int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if q.items.grow {
+		return 0
+	}
+	return q.items.Cap()
+}
+
+// Put adds v to the queue, waiting for space until ctx is done.
+// ErrClosed is returned once the queue is closed
+func (q *BlockingQueue[T]) Put(ctx context.Context, v T) error {
+	for {
+		q.mutex.Lock()
+		hook := q.hook
+		if q.closed {
+			q.mutex.Unlock()
+			if hook != nil {
+				hook.OnDrop(v, ErrClosed)
+			}
+			return ErrClosed
+		}
+		if !q.items.IsFull() {
+			q.push(v)
+			depth := q.items.Len()
+			q.mutex.Unlock()
+			if hook != nil {
+				hook.OnEnqueue(v, depth)
+			}
+			return nil
+		}
+		wait := q.notFull.wait()
+		q.mutex.Unlock()
+
+		select {
+		case <-wait:
+		case <-ctx.Done():
+			if hook != nil {
+				hook.OnDrop(v, ctx.Err())
+			}
+			return ctx.Err()
+		}
+	}
+}
+
+// Take removes the element at the front of the queue, waiting for one until ctx is done.
+// Once the queue is closed the remaining elements can still be taken, then ErrClosed is returned
+func (q *BlockingQueue[T]) Take(ctx context.Context) (T, error) {
+	for {
+		q.mutex.Lock()
+		if !q.items.IsEmpty() {
+			v, wait := q.pop()
+			depth := q.items.Len()
+			hook := q.hook
+			q.mutex.Unlock()
+			if hook != nil {
+				hook.OnDequeue(v, wait, depth)
+			}
+			return v, nil
+		}
+		if q.closed {
+			q.mutex.Unlock()
+			var zero T
+			return zero, ErrClosed
+		}
+		wait := q.notEmpty.wait()
+		q.mutex.Unlock()
+
+		select {
+		case <-wait:
+		case <-ctx.Done():
+			var zero T
+			return zero, ctx.Err()
+		}
+	}
+}
+
+// TakeN waits for at least one element and removes up to n elements from the queue,
+// it returns immediately without removing anything if n <= 0
+func (q *BlockingQueue[T]) TakeN(ctx context.Context, n int) ([]T, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+	v, err := q.Take(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []T{v}
+	var waits []time.Duration
+	q.mutex.Lock()
+	for len(items) < n && !q.items.IsEmpty() {
+		v, wait := q.pop()
+		items = append(items, v)
+		waits = append(waits, wait)
+	}
+	depth := q.items.Len()
+	hook := q.hook
+	q.mutex.Unlock()
+
+	if hook != nil {
+		for i, wait := range waits {
+			hook.OnDequeue(items[i+1], wait, depth+len(waits)-i-1)
+		}
+	}
+	return items, nil
+}
+
+// Offer adds v to the queue, waiting at most timeout for space.
+// ErrFull is returned if no space became available in time
+func (q *BlockingQueue[T]) Offer(v T, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := q.Put(ctx, v); err != nil {
+		if err == context.DeadlineExceeded {
+			return ErrFull
+		}
+		return err
+	}
+	return nil
+}
+
+// Poll removes the element at the front of the queue, waiting at most timeout for one.
+// ErrEmpty is returned if no element became available in time
+func (q *BlockingQueue[T]) Poll(timeout time.Duration) (T, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	v, err := q.Take(ctx)
+	if err == context.DeadlineExceeded {
+		return v, ErrEmpty
+	}
+	return v, err
+}
+
+// Close wakes up all waiting producers and consumers, further Put calls fail with ErrClosed
+// while consumers can drain the remaining elements
+func (q *BlockingQueue[T]) Close() {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if q.closed {
+		return
+	}
+	q.closed = true
+	q.notEmpty.broadcast()
+	q.notFull.broadcast()
+}
+
+// IsClosed reports whether Close has been called
+func (q *BlockingQueue[T]) IsClosed() bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return q.closed
+}
+
+// push 调用方需持有锁
+func (q *BlockingQueue[T]) push(v T) {
+	_ = q.items.Push(v)
+	pushEnqueueTime(q.enqueued)
+	q.notEmpty.broadcast()
+}
+
+// pop 调用方需持有锁，返回元素和它在队列中的等待时间，未设置 hook 时等待时间为 0
+func (q *BlockingQueue[T]) pop() (T, time.Duration) {
+	v, _ := q.items.Pop()
+	wait := popWait(q.enqueued)
+	q.notFull.broadcast()
+	return v, wait
+}
@@ -0,0 +1,164 @@
+package queue
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBlockingQueueFIFO(t *testing.T) {
+	ctx := context.Background()
+	q := NewBlockingQueue[int](0)
+	for i := 0; i < 100; i++ {
+		if err := q.Put(ctx, i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 100; i++ {
+		v, err := q.Take(ctx)
+		if err != nil || v != i {
+			t.Fatalf("Take: got %d %v, want %d", v, err, i)
+		}
+	}
+}
+
+func TestBlockingQueuePutWaitsForSpace(t *testing.T) {
+	ctx := context.Background()
+	q := NewBlockingQueue[int](1)
+	_ = q.Put(ctx, 1)
+
+	if err := q.Offer(2, 10*time.Millisecond); err != ErrFull {
+		t.Fatalf("Offer on a full queue: got %v, want ErrFull", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- q.Put(ctx, 2)
+	}()
+	select {
+	case err := <-done:
+		t.Fatalf("Put returned %v on a full queue", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+	if v, _ := q.Take(ctx); v != 1 {
+		t.Fatalf("Take: got %d, want 1", v)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := q.Take(ctx); v != 2 {
+		t.Fatalf("Take: got %d, want 2", v)
+	}
+}
+
+func TestBlockingQueueTakeN(t *testing.T) {
+	ctx := context.Background()
+	q := NewBlockingQueue[int](0)
+	for i := 0; i < 5; i++ {
+		_ = q.Put(ctx, i)
+	}
+	if items, err := q.TakeN(ctx, 0); items != nil || err != nil {
+		t.Fatalf("TakeN(0): got %v %v", items, err)
+	}
+	items, err := q.TakeN(ctx, 3)
+	if err != nil || len(items) != 3 || items[0] != 0 || items[2] != 2 {
+		t.Fatalf("TakeN(3): got %v %v", items, err)
+	}
+	items, _ = q.TakeN(ctx, 10)
+	if len(items) != 2 {
+		t.Fatalf("TakeN(10): got %v, want the 2 remaining elements", items)
+	}
+}
+
+func TestBlockingQueuePoll(t *testing.T) {
+	q := NewBlockingQueue[int](0)
+	if _, err := q.Poll(10 * time.Millisecond); err != ErrEmpty {
+		t.Fatalf("Poll on an empty queue: got %v, want ErrEmpty", err)
+	}
+}
+
+func TestBlockingQueueClose(t *testing.T) {
+	ctx := context.Background()
+	q := NewBlockingQueue[int](0)
+	_ = q.Put(ctx, 1)
+
+	q.Close()
+	if err := q.Put(ctx, 2); err != ErrClosed {
+		t.Fatalf("Put after Close: got %v, want ErrClosed", err)
+	}
+	if v, err := q.Take(ctx); err != nil || v != 1 {
+		t.Fatalf("remaining elements should be taken after Close: got %d %v", v, err)
+	}
+	if _, err := q.Take(ctx); err != ErrClosed {
+		t.Fatalf("Take on a closed empty queue: got %v, want ErrClosed", err)
+	}
+}
+
+func TestBlockingQueueCloseWakesConsumers(t *testing.T) {
+	q := NewBlockingQueue[int](0)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := q.Take(context.Background()); err != ErrClosed {
+				t.Errorf("Take: got %v, want ErrClosed", err)
+			}
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	q.Close()
+	wg.Wait()
+}
+
+func TestBlockingQueueConcurrent(t *testing.T) {
+	const producers, perProducer = 4, 1000
+	ctx := context.Background()
+	q := NewBlockingQueue[int](16)
+
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			for i := 0; i < perProducer; i++ {
+				if err := q.Put(ctx, p*perProducer+i); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}(p)
+	}
+
+	seen := make([]bool, producers*perProducer)
+	var mutex sync.Mutex
+	var consumers sync.WaitGroup
+	for c := 0; c < 4; c++ {
+		consumers.Add(1)
+		go func() {
+			defer consumers.Done()
+			for {
+				v, err := q.Take(ctx)
+				if err != nil {
+					return
+				}
+				mutex.Lock()
+				if seen[v] {
+					t.Errorf("value %d taken twice", v)
+				}
+				seen[v] = true
+				mutex.Unlock()
+			}
+		}()
+	}
+
+	wg.Wait()
+	q.Close()
+	consumers.Wait()
+	for v, ok := range seen {
+		if !ok {
+			t.Fatalf("value %d was lost", v)
+		}
+	}
+}
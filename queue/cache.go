@@ -0,0 +1,466 @@
+package queue
+
+import (
+	"sync"
+	"time"
+)
+
+// 缓存，LRU 基于双向链表，LFU 基于索引优先级队列
+// 过期的条目在访问时惰性删除，设置 CleanupInterval 后还会在后台定期清理；
+// 设置了过期时间的条目另外按过期时间放入一个堆，清理时只需要检查堆顶
+
+// EvictionPolicy 容量不足时的淘汰策略
+type EvictionPolicy int
+
+const (
+	LRU EvictionPolicy = iota // 淘汰最久未被访问的条目
+	LFU                       // 淘汰访问次数最少的条目，次数相同时淘汰最久未被访问的
+)
+
+// EvictReason 条目被移出缓存的原因
+type EvictReason int
+
+const (
+	EvictCapacity EvictReason = iota // 超出条目数或成本上限
+	EvictExpired                     // 已过期
+	EvictDeleted                     // 被 Delete、Purge 删除或被 Set 覆盖
+)
+
+type CacheOptions[K comparable, V any] struct {
+	Policy          EvictionPolicy
+	MaxEntries      int                        // 最大条目数，0 表示不限制
+	MaxCost         int64                      // 最大总成本，0 表示不限制
+	Cost            func(key K, value V) int64 // 条目的成本，默认为 1
+	TTL             time.Duration              // 默认过期时间，0 表示不过期
+	CleanupInterval time.Duration              // 后台清理过期条目的间隔，0 表示只在访问时清理
+	OnEvict         func(K, V, EvictReason)    // 条目被移出缓存时调用，调用时不持有缓存的锁
+	Clock           Clock                      // 默认为 SystemClock
+}
+
+// CacheStats 缓存的统计信息
+type CacheStats struct {
+	Hits        uint64
+	Misses      uint64
+	Evictions   uint64 // 因容量不足被淘汰的条目数
+	Expirations uint64 // 因过期被删除的条目数
+}
+
+// HitRate returns the ratio of hits to lookups
+func (s CacheStats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+type cacheEntry[K comparable, V any] struct {
+	key    K
+	value  V
+	cost   int64
+	expire time.Time
+	freq   uint64
+	access uint64 // 最近一次访问的序号
+
+	node   *LinkedNode[*cacheEntry[K, V]]
+	handle *Handle[*cacheEntry[K, V]]
+	expiry *Handle[*cacheEntry[K, V]] // 在过期堆中的句柄，未设置过期时间时为 nil
+}
+
+type evicted[K comparable, V any] struct {
+	key    K
+	value  V
+	reason EvictReason
+}
+
+type Cache[K comparable, V any] struct {
+	mutex   sync.Mutex
+	opts    CacheOptions[K, V]
+	entries map[K]*cacheEntry[K, V]
+	lru     LinkedList[*cacheEntry[K, V]] // 队头为最近访问的条目
+	lfu     *IndexedPriorityQueue[*cacheEntry[K, V]]
+	expiry  *IndexedPriorityQueue[*cacheEntry[K, V]] // 设置了过期时间的条目，堆顶最先过期
+	cost    int64
+	access  uint64
+	stats   CacheStats
+	stop    chan struct{}
+}
+
+func NewCache[K comparable, V any](opts CacheOptions[K, V]) *Cache[K, V] {
+	if opts.Clock == nil {
+		opts.Clock = SystemClock
+	}
+	c := &Cache[K, V]{
+		opts:    opts,
+		entries: make(map[K]*cacheEntry[K, V]),
+		lfu: NewIndexedPriorityQueue(func(a, b *cacheEntry[K, V]) bool {
+			if a.freq == b.freq {
+				return a.access < b.access
+			}
+			return a.freq < b.freq
+		}),
+		expiry: NewIndexedPriorityQueue(func(a, b *cacheEntry[K, V]) bool {
+			return a.expire.Before(b.expire)
+		}),
+	}
+	if opts.CleanupInterval > 0 {
+		c.stop = make(chan struct{})
+		go c.janitor(opts.CleanupInterval, c.stop)
+	}
+	return c
+}
+
+func (c *Cache[K, V]) janitor(interval time.Duration, stop chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.DeleteExpired()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// Close stops the background cleanup
+func (c *Cache[K, V]) Close() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.stop != nil {
+		close(c.stop)
+		c.stop = nil
+	}
+}
+
+// Len returns the number of entries, including expired ones not cleaned up yet
+func (c *Cache[K, V]) Len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return len(c.entries)
+}
+
+// Cost returns the total cost of the entries
+func (c *Cache[K, V]) Cost() int64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.cost
+}
+
+// Stats returns a copy of the hit and eviction counters
+func (c *Cache[K, V]) Stats() CacheStats {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.stats
+}
+
+// Get returns the value stored for key and records the access
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	var out []evicted[K, V]
+	c.mutex.Lock()
+	e, ok := c.entries[key]
+	if ok && c.expired(e, c.opts.Clock.Now()) {
+		c.remove(e)
+		c.stats.Expirations++
+		out = append(out, evicted[K, V]{e.key, e.value, EvictExpired})
+		ok = false
+	}
+	if ok {
+		c.stats.Hits++
+		c.touch(e)
+	} else {
+		c.stats.Misses++
+	}
+	c.mutex.Unlock()
+	c.notify(out)
+
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	return e.value, true
+}
+
+// Peek returns the value stored for key without recording the access
+func (c *Cache[K, V]) Peek(key K) (V, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	e, ok := c.entries[key]
+	if !ok || c.expired(e, c.opts.Clock.Now()) {
+		var zero V
+		return zero, false
+	}
+	return e.value, true
+}
+
+// Set stores value for key with the default TTL
+func (c *Cache[K, V]) Set(key K, value V) {
+	c.SetWithTTL(key, value, c.opts.TTL)
+}
+
+// SetWithTTL stores value for key, it expires after ttl unless ttl is 0.
+// A value costing more than MaxCost on its own is not stored and is reported to OnEvict
+func (c *Cache[K, V]) SetWithTTL(key K, value V, ttl time.Duration) {
+	c.mutex.Lock()
+	var out []evicted[K, V]
+	if old, ok := c.entries[key]; ok {
+		c.remove(old)
+		out = append(out, evicted[K, V]{old.key, old.value, EvictDeleted})
+	}
+
+	e := &cacheEntry[K, V]{key: key, value: value, cost: 1}
+	if c.opts.Cost != nil {
+		e.cost = c.opts.Cost(key, value)
+	}
+	now := c.opts.Clock.Now()
+	if ttl > 0 {
+		e.expire = now.Add(ttl)
+	}
+
+	// 插入前先腾出空间，新条目不参与淘汰：超出上限时先清理过期条目，再按策略淘汰
+	if c.overflow(e.cost) {
+		out = append(out, c.deleteExpired(now)...)
+	}
+	for len(c.entries) > 0 && c.overflow(e.cost) {
+		victim := c.victim()
+		c.remove(victim)
+		c.stats.Evictions++
+		out = append(out, evicted[K, V]{victim.key, victim.value, EvictCapacity})
+	}
+	if c.overflow(e.cost) {
+		// 单个条目的成本已超过 MaxCost，无法放入缓存
+		c.stats.Evictions++
+		out = append(out, evicted[K, V]{key, value, EvictCapacity})
+		c.mutex.Unlock()
+		c.notify(out)
+		return
+	}
+
+	c.entries[key] = e
+	c.cost += e.cost
+	if c.opts.Policy == LFU {
+		c.access++
+		e.access = c.access
+		e.handle = c.lfu.Push(e)
+	} else {
+		e.node = c.lru.PushFront(e)
+	}
+	if !e.expire.IsZero() {
+		e.expiry = c.expiry.Push(e)
+	}
+	c.mutex.Unlock()
+	c.notify(out)
+}
+
+// Delete removes key from the cache
+func (c *Cache[K, V]) Delete(key K) bool {
+	c.mutex.Lock()
+	e, ok := c.entries[key]
+	if ok {
+		c.remove(e)
+	}
+	c.mutex.Unlock()
+	if ok {
+		c.notify([]evicted[K, V]{{e.key, e.value, EvictDeleted}})
+	}
+	return ok
+}
+
+// DeleteExpired removes all expired entries
+func (c *Cache[K, V]) DeleteExpired() {
+	c.mutex.Lock()
+	out := c.deleteExpired(c.opts.Clock.Now())
+	c.mutex.Unlock()
+	c.notify(out)
+}
+
+// Purge removes all entries
+func (c *Cache[K, V]) Purge() {
+	c.mutex.Lock()
+	out := make([]evicted[K, V], 0, len(c.entries))
+	for _, e := range c.entries {
+		out = append(out, evicted[K, V]{e.key, e.value, EvictDeleted})
+	}
+	c.entries = make(map[K]*cacheEntry[K, V])
+	c.lru.Clear()
+	c.lfu.Clear()
+	c.expiry.Clear()
+	c.cost = 0
+	c.mutex.Unlock()
+	c.notify(out)
+}
+
+// Keys returns the keys of the entries that have not expired
+func (c *Cache[K, V]) Keys() []K {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	now := c.opts.Clock.Now()
+	keys := make([]K, 0, len(c.entries))
+	for k, e := range c.entries {
+		if !c.expired(e, now) {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+func (c *Cache[K, V]) expired(e *cacheEntry[K, V], now time.Time) bool {
+	return !e.expire.IsZero() && !now.Before(e.expire)
+}
+
+// overflow 加入一个成本为 cost 的新条目后是否超出上限，调用方需持有锁
+func (c *Cache[K, V]) overflow(cost int64) bool {
+	return (c.opts.MaxEntries > 0 && len(c.entries)+1 > c.opts.MaxEntries) ||
+		(c.opts.MaxCost > 0 && c.cost+cost > c.opts.MaxCost)
+}
+
+// touch 记录一次访问，调用方需持有锁
+func (c *Cache[K, V]) touch(e *cacheEntry[K, V]) {
+	if c.opts.Policy == LFU {
+		c.access++
+		e.freq++
+		e.access = c.access
+		c.lfu.Fix(e.handle)
+	} else {
+		c.lru.MoveToFront(e.node)
+	}
+}
+
+// victim 按淘汰策略选出下一个被淘汰的条目，调用方需持有锁
+func (c *Cache[K, V]) victim() *cacheEntry[K, V] {
+	if c.opts.Policy == LFU {
+		h, _ := c.lfu.Peek()
+		return h.Value
+	}
+	return c.lru.Back().Value
+}
+
+// remove 调用方需持有锁
+func (c *Cache[K, V]) remove(e *cacheEntry[K, V]) {
+	delete(c.entries, e.key)
+	c.cost -= e.cost
+	if e.node != nil {
+		c.lru.Remove(e.node)
+	}
+	if e.handle != nil {
+		c.lfu.Remove(e.handle)
+	}
+	if e.expiry != nil {
+		c.expiry.Remove(e.expiry)
+	}
+}
+
+// deleteExpired 从过期堆的堆顶开始删除已过期的条目，只访问过期的条目，调用方需持有锁
+func (c *Cache[K, V]) deleteExpired(now time.Time) []evicted[K, V] {
+	var out []evicted[K, V]
+	for !c.expiry.IsEmpty() {
+		h, _ := c.expiry.Peek()
+		e := h.Value
+		if !c.expired(e, now) {
+			break
+		}
+		c.remove(e)
+		c.stats.Expirations++
+		out = append(out, evicted[K, V]{e.key, e.value, EvictExpired})
+	}
+	return out
+}
+
+func (c *Cache[K, V]) notify(out []evicted[K, V]) {
+	if c.opts.OnEvict == nil {
+		return
+	}
+	for _, e := range out {
+		c.opts.OnEvict(e.key, e.value, e.reason)
+	}
+}
+
+// ShardedCache 分片缓存，按照 key 的哈希值分散到多个 Cache 上以降低锁竞争
+type ShardedCache[K comparable, V any] struct {
+	shards []*Cache[K, V]
+	hash   func(K) uint64
+}
+
+// NewShardedCache returns a cache split into n shards selected by hash,
+// MaxEntries and MaxCost are divided evenly between the shards
+func NewShardedCache[K comparable, V any](n int, hash func(K) uint64, opts CacheOptions[K, V]) *ShardedCache[K, V] {
+	if n < 1 {
+		n = 1
+	}
+	if opts.MaxEntries > 0 {
+		opts.MaxEntries = (opts.MaxEntries + n - 1) / n
+	}
+	if opts.MaxCost > 0 {
+		opts.MaxCost = (opts.MaxCost + int64(n) - 1) / int64(n)
+	}
+	c := &ShardedCache[K, V]{
+		shards: make([]*Cache[K, V], n),
+		hash:   hash,
+	}
+	for i := range c.shards {
+		c.shards[i] = NewCache(opts)
+	}
+	return c
+}
+
+func (c *ShardedCache[K, V]) shard(key K) *Cache[K, V] {
+	return c.shards[c.hash(key)%uint64(len(c.shards))]
+}
+
+func (c *ShardedCache[K, V]) Get(key K) (V, bool) {
+	return c.shard(key).Get(key)
+}
+
+func (c *ShardedCache[K, V]) Peek(key K) (V, bool) {
+	return c.shard(key).Peek(key)
+}
+
+func (c *ShardedCache[K, V]) Set(key K, value V) {
+	c.shard(key).Set(key, value)
+}
+
+func (c *ShardedCache[K, V]) SetWithTTL(key K, value V, ttl time.Duration) {
+	c.shard(key).SetWithTTL(key, value, ttl)
+}
+
+func (c *ShardedCache[K, V]) Delete(key K) bool {
+	return c.shard(key).Delete(key)
+}
+
+func (c *ShardedCache[K, V]) DeleteExpired() {
+	for _, s := range c.shards {
+		s.DeleteExpired()
+	}
+}
+
+func (c *ShardedCache[K, V]) Purge() {
+	for _, s := range c.shards {
+		s.Purge()
+	}
+}
+
+func (c *ShardedCache[K, V]) Len() int {
+	n := 0
+	for _, s := range c.shards {
+		n += s.Len()
+	}
+	return n
+}
+
+// Stats returns the counters summed over all shards
+func (c *ShardedCache[K, V]) Stats() CacheStats {
+	var stats CacheStats
+	for _, s := range c.shards {
+		st := s.Stats()
+		stats.Hits += st.Hits
+		stats.Misses += st.Misses
+		stats.Evictions += st.Evictions
+		stats.Expirations += st.Expirations
+	}
+	return stats
+}
+
+func (c *ShardedCache[K, V]) Close() {
+	for _, s := range c.shards {
+		s.Close()
+	}
+}
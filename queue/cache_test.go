@@ -0,0 +1,166 @@
+package queue
+
+import (
+	"sort"
+	"testing"
+	"time"
+)
+
+func sortedKeys(c *Cache[string, int]) []string {
+	keys := c.Keys()
+	sort.Strings(keys)
+	return keys
+}
+
+func expectKeys(t *testing.T, c *Cache[string, int], want ...string) {
+	t.Helper()
+	got := sortedKeys(c)
+	if len(got) != len(want) {
+		t.Fatalf("Keys: got %v, want %v", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("Keys: got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestCacheLRU(t *testing.T) {
+	var evictedKeys []string
+	c := NewCache(CacheOptions[string, int]{
+		MaxEntries: 2,
+		OnEvict: func(k string, _ int, reason EvictReason) {
+			if reason == EvictCapacity {
+				evictedKeys = append(evictedKeys, k)
+			}
+		},
+	})
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Get("a")
+	c.Set("c", 3)
+	expectKeys(t, c, "a", "c")
+	if len(evictedKeys) != 1 || evictedKeys[0] != "b" {
+		t.Fatalf("evicted %v, want [b]", evictedKeys)
+	}
+}
+
+func TestCacheLFUAdmitsNewKeys(t *testing.T) {
+	var evictedKeys []string
+	c := NewCache(CacheOptions[string, int]{
+		Policy:     LFU,
+		MaxEntries: 2,
+		OnEvict: func(k string, _ int, reason EvictReason) {
+			if reason == EvictCapacity {
+				evictedKeys = append(evictedKeys, k)
+			}
+		},
+	})
+	c.Set("a", 1)
+	c.Get("a")
+	c.Get("a")
+	c.Set("b", 2)
+	c.Get("b")
+	c.Set("c", 3)
+	// b 的访问次数最少，被淘汰的是 b 而不是刚加入的 c
+	expectKeys(t, c, "a", "c")
+	if len(evictedKeys) != 1 || evictedKeys[0] != "b" {
+		t.Fatalf("evicted %v, want [b]", evictedKeys)
+	}
+	if c.Stats().Evictions != 1 {
+		t.Fatalf("Evictions: got %d, want 1", c.Stats().Evictions)
+	}
+}
+
+func TestCacheMaxCost(t *testing.T) {
+	var rejected []string
+	c := NewCache(CacheOptions[string, int]{
+		MaxCost: 10,
+		Cost:    func(_ string, v int) int64 { return int64(v) },
+		OnEvict: func(k string, _ int, reason EvictReason) {
+			if reason == EvictCapacity {
+				rejected = append(rejected, k)
+			}
+		},
+	})
+	c.Set("a", 4)
+	c.Set("b", 4)
+	c.Set("c", 4)
+	expectKeys(t, c, "b", "c")
+	if c.Cost() != 8 {
+		t.Fatalf("Cost: got %d, want 8", c.Cost())
+	}
+
+	// 超过 MaxCost 的条目不会被放入缓存
+	c.Set("huge", 11)
+	if _, ok := c.Peek("huge"); ok {
+		t.Fatal("an entry costing more than MaxCost was stored")
+	}
+	if rejected[len(rejected)-1] != "huge" {
+		t.Fatalf("evicted %v, the oversized entry should be reported last", rejected)
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	clock := newFakeClock()
+	var expired []string
+	c := NewCache(CacheOptions[string, int]{
+		TTL:   time.Minute,
+		Clock: clock,
+		OnEvict: func(k string, _ int, reason EvictReason) {
+			if reason == EvictExpired {
+				expired = append(expired, k)
+			}
+		},
+	})
+	c.Set("a", 1)
+	c.SetWithTTL("b", 2, 0)
+	clock.Advance(time.Minute)
+
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("Get returned an expired entry")
+	}
+	if v, ok := c.Get("b"); !ok || v != 2 {
+		t.Fatalf("Get of an entry without TTL: got %d %v", v, ok)
+	}
+	stats := c.Stats()
+	if stats.Hits != 1 || stats.Misses != 1 || stats.Expirations != 1 || stats.HitRate() != 0.5 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+	if len(expired) != 1 || expired[0] != "a" {
+		t.Fatalf("expired %v, want [a]", expired)
+	}
+}
+
+func TestCacheOverflowDeletesExpiredFirst(t *testing.T) {
+	clock := newFakeClock()
+	var reasons []EvictReason
+	c := NewCache(CacheOptions[string, int]{
+		MaxEntries: 2,
+		Clock:      clock,
+		OnEvict: func(_ string, _ int, reason EvictReason) {
+			reasons = append(reasons, reason)
+		},
+	})
+	c.Set("a", 1)
+	c.SetWithTTL("b", 2, time.Minute)
+	clock.Advance(time.Minute)
+	c.Set("c", 3)
+	// b 已过期，清理后有空位，最久未访问的 a 不会被淘汰
+	expectKeys(t, c, "a", "c")
+	if len(reasons) != 1 || reasons[0] != EvictExpired {
+		t.Fatalf("reasons %v, want only EvictExpired", reasons)
+	}
+}
+
+func BenchmarkCacheSetFull(b *testing.B) {
+	const size = 100000
+	c := NewCache(CacheOptions[int, int]{MaxEntries: size})
+	for i := 0; i < size; i++ {
+		c.Set(i, i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.Set(size+i, i)
+	}
+}
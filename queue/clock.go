@@ -0,0 +1,36 @@
+package queue
+
+import "time"
+
+// Clock 时间源，测试时可以替换为可控的实现
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer 对 time.Timer 的抽象
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+}
+
+// SystemClock 基于 time 包的系统时钟
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) NewTimer(d time.Duration) Timer {
+	return systemTimer{time.NewTimer(d)}
+}
+
+type systemTimer struct {
+	*time.Timer
+}
+
+func (t systemTimer) C() <-chan time.Time {
+	return t.Timer.C
+}
@@ -0,0 +1,114 @@
+package queue
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock 手动推进的时钟，Advance 时触发到期的定时器
+type fakeClock struct {
+	mutex  sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+type fakeTimer struct {
+	clock *fakeClock
+	due   time.Time
+	c     chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) NewTimer(d time.Duration) Timer {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	t := &fakeTimer{clock: c, due: c.now.Add(d), c: make(chan time.Time, 1)}
+	if d <= 0 {
+		t.c <- c.now
+		return t
+	}
+	c.timers = append(c.timers, t)
+	return t
+}
+
+// Advance moves the clock forward by d and fires the timers that became due
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.now = c.now.Add(d)
+	pending := c.timers[:0]
+	for _, t := range c.timers {
+		if t.due.After(c.now) {
+			pending = append(pending, t)
+			continue
+		}
+		t.c <- c.now
+	}
+	c.timers = pending
+}
+
+// Timers returns the number of pending timers
+func (c *fakeClock) Timers() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return len(c.timers)
+}
+
+// waitTimers waits until n timers are pending, which means the goroutines under test are blocked on them
+func (c *fakeClock) waitTimers(t *testing.T, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for c.Timers() < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout waiting for %d timers, got %d", n, c.Timers())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// waitTimerAt waits until a timer due at due is pending
+func (c *fakeClock) waitTimerAt(t *testing.T, due time.Time) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		c.mutex.Lock()
+		for _, timer := range c.timers {
+			if timer.due.Equal(due) {
+				c.mutex.Unlock()
+				return
+			}
+		}
+		c.mutex.Unlock()
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout waiting for a timer due at %v", due)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTimer) Stop() bool {
+	c := t.clock
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for i, pending := range c.timers {
+		if pending == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,257 @@
+package queue
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// 延迟队列，元素到期后才能被取出
+
+type delayItem[T any] struct {
+	key   string
+	value T
+	due   time.Time
+	seq   uint64    // 到期时间相同时按入队顺序出队
+	put   time.Time // 入队时间，用于计算 hook 的等待时间
+}
+
+func delayLess[T any](a, b *delayItem[T]) bool {
+	if a.due.Equal(b.due) {
+		return a.seq < b.seq
+	}
+	return a.due.Before(b.due)
+}
+
+type DelayQueue[T any] struct {
+	mutex   sync.Mutex
+	clock   Clock
+	items   *IndexedPriorityQueue[*delayItem[T]]
+	keys    map[string]*Handle[*delayItem[T]]
+	changed signal // 队头发生变化或队列关闭
+	seq     uint64
+	closed  bool
+	hook    Hook[T]
+}
+
+func NewDelayQueue[T any]() *DelayQueue[T] {
+	return NewDelayQueueWithClock[T](SystemClock)
+}
+
+// NewDelayQueueWithClock returns a delay queue driven by clock
+func NewDelayQueueWithClock[T any](clock Clock) *DelayQueue[T] {
+	return &DelayQueue[T]{
+		clock: clock,
+		items: NewIndexedPriorityQueue(delayLess[T]),
+		keys:  make(map[string]*Handle[*delayItem[T]]),
+	}
+}
+
+// SetHook reports the events of the queue to hook, a nil hook removes it.
+// The wait of a dequeued element is measured from its Put, cancelled or replaced elements are not reported
+func (q *DelayQueue[T]) SetHook(hook Hook[T]) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.hook = hook
+}
+
+// Len returns the number of pending elements, due or not
+func (q *DelayQueue[T]) Len() int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return q.items.Len()
+}
+
+// Put adds v to the queue, it can be taken once delay has elapsed
+func (q *DelayQueue[T]) Put(key string, v T, delay time.Duration) error {
+	return q.PutAt(key, v, q.clock.Now().Add(delay))
+}
+
+// PutAt adds v to the queue, it can be taken once due is reached.
+// A non empty key allows to cancel the element, putting an element with a pending key replaces it
+func (q *DelayQueue[T]) PutAt(key string, v T, due time.Time) error {
+	q.mutex.Lock()
+	hook := q.hook
+	if q.closed {
+		q.mutex.Unlock()
+		if hook != nil {
+			hook.OnDrop(v, ErrClosed)
+		}
+		return ErrClosed
+	}
+
+	q.seq++
+	item := &delayItem[T]{key: key, value: v, due: due, seq: q.seq, put: q.clock.Now()}
+	if h, ok := q.keys[key]; ok && key != "" {
+		q.items.Update(h, item)
+	} else {
+		h = q.items.Push(item)
+		if key != "" {
+			q.keys[key] = h
+		}
+	}
+	depth := q.items.Len()
+	q.changed.broadcast()
+	q.mutex.Unlock()
+
+	if hook != nil {
+		hook.OnEnqueue(v, depth)
+	}
+	return nil
+}
+
+// Cancel removes the pending element put with key
+func (q *DelayQueue[T]) Cancel(key string) bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	h, ok := q.keys[key]
+	if !ok {
+		return false
+	}
+	delete(q.keys, key)
+	q.items.Remove(h)
+	q.changed.broadcast()
+	return true
+}
+
+// Due returns the due time of the pending element put with key
+func (q *DelayQueue[T]) Due(key string) (time.Time, bool) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	h, ok := q.keys[key]
+	if !ok {
+		return time.Time{}, false
+	}
+	return h.Value.due, true
+}
+
+// Take removes the earliest element, waiting until it is due or ctx is done.
+// ErrClosed is returned once the queue is closed
+func (q *DelayQueue[T]) Take(ctx context.Context) (T, error) {
+	var zero T
+	for {
+		q.mutex.Lock()
+		if q.closed {
+			q.mutex.Unlock()
+			return zero, ErrClosed
+		}
+
+		var timer Timer
+		wait := q.changed.wait()
+		if h, err := q.items.Peek(); err == nil {
+			delay := h.Value.due.Sub(q.clock.Now())
+			if delay <= 0 {
+				hook, wait, depth := q.pop()
+				q.mutex.Unlock()
+				if hook != nil {
+					hook.OnDequeue(h.Value.value, wait, depth)
+				}
+				return h.Value.value, nil
+			}
+			timer = q.clock.NewTimer(delay)
+		}
+		q.mutex.Unlock()
+
+		var expired <-chan time.Time
+		if timer != nil {
+			expired = timer.C()
+		}
+		select {
+		case <-expired:
+		case <-wait:
+		case <-ctx.Done():
+			if timer != nil {
+				timer.Stop()
+			}
+			return zero, ctx.Err()
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+// TryTake removes the earliest element if it is already due, otherwise ErrEmpty is returned
+func (q *DelayQueue[T]) TryTake() (T, error) {
+	q.mutex.Lock()
+	var zero T
+	if q.closed {
+		q.mutex.Unlock()
+		return zero, ErrClosed
+	}
+	h, err := q.items.Peek()
+	if err != nil || h.Value.due.After(q.clock.Now()) {
+		q.mutex.Unlock()
+		return zero, ErrEmpty
+	}
+	hook, wait, depth := q.pop()
+	q.mutex.Unlock()
+
+	if hook != nil {
+		hook.OnDequeue(h.Value.value, wait, depth)
+	}
+	return h.Value.value, nil
+}
+
+// takeClosed 队列关闭后不等待到期时间，直接取出最早的元素，队列为空时返回 ErrClosed
+func (q *DelayQueue[T]) takeClosed() (T, error) {
+	q.mutex.Lock()
+	if q.items.IsEmpty() {
+		q.mutex.Unlock()
+		var zero T
+		return zero, ErrClosed
+	}
+	h, _ := q.items.Peek()
+	hook, wait, depth := q.pop()
+	q.mutex.Unlock()
+
+	if hook != nil {
+		hook.OnDequeue(h.Value.value, wait, depth)
+	}
+	return h.Value.value, nil
+}
+
+// Drain removes all pending elements regardless of their due time and returns them in due order
+func (q *DelayQueue[T]) Drain() []T {
+	q.mutex.Lock()
+	now := q.clock.Now()
+	items := make([]T, 0, q.items.Len())
+	waits := make([]time.Duration, 0, q.items.Len())
+	for !q.items.IsEmpty() {
+		item, _ := q.items.Pop()
+		items = append(items, item.value)
+		waits = append(waits, now.Sub(item.put))
+	}
+	q.keys = make(map[string]*Handle[*delayItem[T]])
+	q.changed.broadcast()
+	hook := q.hook
+	q.mutex.Unlock()
+
+	if hook != nil {
+		for i, v := range items {
+			hook.OnDequeue(v, waits[i], len(items)-i-1)
+		}
+	}
+	return items
+}
+
+// Close wakes up all waiting consumers, further calls to Put and Take fail with ErrClosed.
+// Pending elements can still be retrieved with Drain
+func (q *DelayQueue[T]) Close() {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if q.closed {
+		return
+	}
+	q.closed = true
+	q.changed.broadcast()
+}
+
+// pop 调用方需持有锁，移除队头元素，返回 hook、元素的等待时间和出队后的队列长度
+func (q *DelayQueue[T]) pop() (Hook[T], time.Duration, int) {
+	item, _ := q.items.Pop()
+	if item.key != "" {
+		delete(q.keys, item.key)
+	}
+	return q.hook, q.clock.Now().Sub(item.put), q.items.Len()
+}
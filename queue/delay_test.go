@@ -0,0 +1,127 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDelayQueueOrder(t *testing.T) {
+	clock := newFakeClock()
+	q := NewDelayQueueWithClock[string](clock)
+	_ = q.Put("", "c", 3*time.Second)
+	_ = q.Put("", "a", time.Second)
+	_ = q.Put("", "b", 2*time.Second)
+	_ = q.Put("", "a2", time.Second)
+
+	if _, err := q.TryTake(); err != ErrEmpty {
+		t.Fatalf("TryTake before due: got %v, want ErrEmpty", err)
+	}
+	clock.Advance(3 * time.Second)
+	for _, want := range []string{"a", "a2", "b", "c"} {
+		got, err := q.TryTake()
+		if err != nil || got != want {
+			t.Fatalf("TryTake: got %q %v, want %q", got, err, want)
+		}
+	}
+}
+
+func TestDelayQueueTakeWaitsForClock(t *testing.T) {
+	clock := newFakeClock()
+	q := NewDelayQueueWithClock[int](clock)
+	_ = q.Put("", 1, time.Minute)
+
+	result := make(chan int, 1)
+	go func() {
+		v, err := q.Take(context.Background())
+		if err != nil {
+			t.Error(err)
+		}
+		result <- v
+	}()
+
+	clock.waitTimers(t, 1)
+	select {
+	case v := <-result:
+		t.Fatalf("Take returned %d before the element was due", v)
+	default:
+	}
+	clock.Advance(time.Minute)
+	select {
+	case v := <-result:
+		if v != 1 {
+			t.Fatalf("got %d, want 1", v)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Take did not return after the element became due")
+	}
+}
+
+func TestDelayQueueEarlierPutWakesTake(t *testing.T) {
+	clock := newFakeClock()
+	q := NewDelayQueueWithClock[int](clock)
+	_ = q.Put("", 1, time.Hour)
+
+	result := make(chan int, 1)
+	go func() {
+		v, _ := q.Take(context.Background())
+		result <- v
+	}()
+	clock.waitTimers(t, 1)
+
+	// 新的队头让 Take 重新计算等待时间
+	_ = q.Put("", 2, time.Second)
+	clock.waitTimerAt(t, clock.Now().Add(time.Second))
+	clock.Advance(time.Second)
+	select {
+	case v := <-result:
+		if v != 2 {
+			t.Fatalf("got %d, want 2", v)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Take did not return")
+	}
+}
+
+func TestDelayQueueCancelAndReplace(t *testing.T) {
+	clock := newFakeClock()
+	q := NewDelayQueueWithClock[int](clock)
+	_ = q.Put("k", 1, time.Second)
+	_ = q.Put("k", 2, 2*time.Second)
+	if q.Len() != 1 {
+		t.Fatalf("Len: got %d, want 1", q.Len())
+	}
+	if due, ok := q.Due("k"); !ok || !due.Equal(clock.Now().Add(2*time.Second)) {
+		t.Fatalf("Due: got %v %v", due, ok)
+	}
+	if !q.Cancel("k") || q.Cancel("k") {
+		t.Fatal("Cancel should succeed once")
+	}
+	clock.Advance(time.Hour)
+	if _, err := q.TryTake(); err != ErrEmpty {
+		t.Fatalf("TryTake after Cancel: got %v, want ErrEmpty", err)
+	}
+}
+
+func TestDelayQueueClose(t *testing.T) {
+	clock := newFakeClock()
+	q := NewDelayQueueWithClock[int](clock)
+	_ = q.Put("", 1, time.Hour)
+
+	errs := make(chan error, 1)
+	go func() {
+		_, err := q.Take(context.Background())
+		errs <- err
+	}()
+	clock.waitTimers(t, 1)
+	q.Close()
+	if err := <-errs; err != ErrClosed {
+		t.Fatalf("Take after Close: got %v, want ErrClosed", err)
+	}
+	if err := q.Put("", 2, 0); err != ErrClosed {
+		t.Fatalf("Put after Close: got %v, want ErrClosed", err)
+	}
+	if items := q.Drain(); len(items) != 1 || items[0] != 1 {
+		t.Fatalf("Drain: got %v", items)
+	}
+}
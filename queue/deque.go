@@ -0,0 +1,234 @@
+package queue
+
+// 双端队列，基于可扩容的环形数组，两端的插入和删除均摊复杂度为 O(1)
+// 元素数量不足容量的 1/4 时缩容，零值即可使用
+
+type Deque[T any] struct {
+	member []T
+	// index & length
+	head, len int
+}
+
+func NewDeque[T any](size int) *Deque[T] {
+	if size < minGrowCap {
+		size = minGrowCap
+	}
+	return &Deque[T]{member: make([]T, size)}
+}
+
+func (d *Deque[T]) Len() int {
+	return d.len
+}
+
+func (d *Deque[T]) IsEmpty() bool {
+	return d.len == 0
+}
+
+// Cap returns the current capacity of the deque
+func (d *Deque[T]) Cap() int {
+	return len(d.member)
+}
+
+// index 将逻辑下标转换为数组下标
+func (d *Deque[T]) index(i int) int {
+	return (d.head + i) % len(d.member)
+}
+
+// PushFront inserts v at the front
+func (d *Deque[T]) PushFront(v T) {
+	d.grow()
+	d.head = (d.head - 1 + len(d.member)) % len(d.member)
+	d.member[d.head] = v
+	d.len++
+}
+
+// PushBack inserts v at the back
+func (d *Deque[T]) PushBack(v T) {
+	d.grow()
+	d.member[d.index(d.len)] = v
+	d.len++
+}
+
+// PopFront removes and returns the front element
+func (d *Deque[T]) PopFront() (T, error) {
+	var zero T
+	if d.len == 0 {
+		return zero, ErrEmpty
+	}
+	v := d.member[d.head]
+	d.member[d.head] = zero
+	d.head = (d.head + 1) % len(d.member)
+	d.len--
+	d.shrink()
+	return v, nil
+}
+
+// PopBack removes and returns the back element
+func (d *Deque[T]) PopBack() (T, error) {
+	var zero T
+	if d.len == 0 {
+		return zero, ErrEmpty
+	}
+	i := d.index(d.len - 1)
+	v := d.member[i]
+	d.member[i] = zero
+	d.len--
+	d.shrink()
+	return v, nil
+}
+
+// Front returns the front element without removing it
+func (d *Deque[T]) Front() (T, error) {
+	if d.len == 0 {
+		var zero T
+		return zero, ErrEmpty
+	}
+	return d.member[d.head], nil
+}
+
+// Back returns the back element without removing it
+func (d *Deque[T]) Back() (T, error) {
+	if d.len == 0 {
+		var zero T
+		return zero, ErrEmpty
+	}
+	return d.member[d.index(d.len-1)], nil
+}
+
+// At returns the i-th element from the front, it panics if i is out of range
+func (d *Deque[T]) At(i int) T {
+	if i < 0 || i >= d.len {
+		panic("queue: Deque index out of range")
+	}
+	return d.member[d.index(i)]
+}
+
+// Set replaces the i-th element from the front, it panics if i is out of range
+func (d *Deque[T]) Set(i int, v T) {
+	if i < 0 || i >= d.len {
+		panic("queue: Deque index out of range")
+	}
+	d.member[d.index(i)] = v
+}
+
+// Rotate rotates the deque n steps to the right, i.e. moves the last n elements to the front,
+// a negative n rotates to the left
+func (d *Deque[T]) Rotate(n int) {
+	if d.len <= 1 {
+		return
+	}
+	n %= d.len
+	if n == 0 {
+		return
+	}
+	if d.len == len(d.member) {
+		// 数组已满时只需移动队头
+		d.head = (d.head - n + d.len) % d.len
+		return
+	}
+	for ; n > 0; n-- {
+		i := d.index(d.len - 1)
+		v := d.member[i]
+		var zero T
+		d.member[i] = zero
+		d.head = (d.head - 1 + len(d.member)) % len(d.member)
+		d.member[d.head] = v
+	}
+	for ; n < 0; n++ {
+		v := d.member[d.head]
+		var zero T
+		d.member[d.head] = zero
+		d.head = (d.head + 1) % len(d.member)
+		d.member[d.index(d.len-1)] = v
+	}
+}
+
+// Clear removes all elements
+func (d *Deque[T]) Clear() {
+	var zero T
+	for i := 0; i < d.len; i++ {
+		d.member[d.index(i)] = zero
+	}
+	d.head, d.len = 0, 0
+}
+
+// Values returns the elements from front to back
+func (d *Deque[T]) Values() []T {
+	values := make([]T, d.len)
+	for i := range values {
+		values[i] = d.member[d.index(i)]
+	}
+	return values
+}
+
+func (d *Deque[T]) grow() {
+	if d.len < len(d.member) {
+		return
+	}
+	size := len(d.member) * 2
+	if size < minGrowCap {
+		size = minGrowCap
+	}
+	d.resize(size)
+}
+
+func (d *Deque[T]) shrink() {
+	if len(d.member) > minGrowCap && d.len <= len(d.member)/4 {
+		d.resize(len(d.member) / 2)
+	}
+}
+
+func (d *Deque[T]) resize(size int) {
+	member := make([]T, size)
+	if d.len > 0 {
+		if d.head+d.len <= len(d.member) {
+			copy(member, d.member[d.head:d.head+d.len])
+		} else {
+			n := copy(member, d.member[d.head:])
+			copy(member[n:], d.member[:d.len-n])
+		}
+	}
+	d.member, d.head = member, 0
+}
+
+// Stack 基于 Deque 的栈，零值即可使用
+type Stack[T any] struct {
+	deque Deque[T]
+}
+
+func NewStack[T any]() *Stack[T] {
+	return &Stack[T]{}
+}
+
+func (s *Stack[T]) Len() int {
+	return s.deque.Len()
+}
+
+func (s *Stack[T]) IsEmpty() bool {
+	return s.deque.IsEmpty()
+}
+
+// Push adds v to the top of the stack
+func (s *Stack[T]) Push(v T) {
+	s.deque.PushBack(v)
+}
+
+// Pop removes and returns the top of the stack
+func (s *Stack[T]) Pop() (T, error) {
+	return s.deque.PopBack()
+}
+
+// Peek returns the top of the stack without removing it
+func (s *Stack[T]) Peek() (T, error) {
+	return s.deque.Back()
+}
+
+// Clear removes all elements
+func (s *Stack[T]) Clear() {
+	s.deque.Clear()
+}
+
+// Values returns the elements from bottom to top
+func (s *Stack[T]) Values() []T {
+	return s.deque.Values()
+}
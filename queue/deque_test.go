@@ -0,0 +1,117 @@
+package queue
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// rotateSlice 将切片向右旋转 n 步，作为 Deque.Rotate 的参照实现
+func rotateSlice(s []int, n int) []int {
+	if len(s) == 0 {
+		return s
+	}
+	n %= len(s)
+	if n < 0 {
+		n += len(s)
+	}
+	return append(append([]int(nil), s[len(s)-n:]...), s[:len(s)-n]...)
+}
+
+func TestDequeRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	var d Deque[int]
+	var want []int
+	for i := 0; i < 5000; i++ {
+		switch op := r.Intn(7); op {
+		case 0:
+			d.PushFront(i)
+			want = append([]int{i}, want...)
+		case 1:
+			d.PushBack(i)
+			want = append(want, i)
+		case 2:
+			v, err := d.PopFront()
+			if len(want) == 0 {
+				if err != ErrEmpty {
+					t.Fatalf("PopFront on an empty deque: got %v, want ErrEmpty", err)
+				}
+				continue
+			}
+			if v != want[0] {
+				t.Fatalf("PopFront: got %d, want %d", v, want[0])
+			}
+			want = want[1:]
+		case 3:
+			v, err := d.PopBack()
+			if len(want) == 0 {
+				if err != ErrEmpty {
+					t.Fatalf("PopBack on an empty deque: got %v, want ErrEmpty", err)
+				}
+				continue
+			}
+			if v != want[len(want)-1] {
+				t.Fatalf("PopBack: got %d, want %d", v, want[len(want)-1])
+			}
+			want = want[:len(want)-1]
+		case 4, 5:
+			n := r.Intn(21) - 10
+			d.Rotate(n)
+			want = rotateSlice(want, n)
+		case 6:
+			if len(want) > 0 {
+				j := r.Intn(len(want))
+				d.Set(j, -i)
+				want[j] = -i
+			}
+		}
+		expectInts(t, d.Values(), want...)
+		if d.Len() > d.Cap() || (d.Cap() > minGrowCap && d.Len() < d.Cap()/4) {
+			t.Fatalf("Len %d with Cap %d, the deque should have grown or shrunk", d.Len(), d.Cap())
+		}
+	}
+}
+
+func TestDequeRotateFull(t *testing.T) {
+	// 元素数等于容量时 Rotate 只移动队头
+	d := NewDeque[int](4)
+	for i := 0; i < 4; i++ {
+		d.PushBack(i)
+	}
+	d.PopFront()
+	d.PushBack(4) // 队头不在数组开头
+	d.Rotate(1)
+	expectInts(t, d.Values(), 4, 1, 2, 3)
+	d.Rotate(-3)
+	expectInts(t, d.Values(), 3, 4, 1, 2)
+	d.Rotate(9)
+	expectInts(t, d.Values(), 2, 3, 4, 1)
+	if d.Cap() != 4 {
+		t.Fatalf("Cap: got %d, want 4", d.Cap())
+	}
+}
+
+func TestDequeShrink(t *testing.T) {
+	d := NewDeque[int](0)
+	for i := 0; i < 64; i++ {
+		d.PushBack(i)
+	}
+	if d.Cap() != 64 {
+		t.Fatalf("Cap after 64 pushes: got %d, want 64", d.Cap())
+	}
+	for i := 0; i < 60; i++ {
+		_, _ = d.PopFront()
+	}
+	if d.Cap() >= 64 {
+		t.Fatalf("Cap after popping most elements: got %d", d.Cap())
+	}
+	expectInts(t, d.Values(), 60, 61, 62, 63)
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("At out of range should panic")
+			}
+		}()
+		d.At(4)
+	}()
+}
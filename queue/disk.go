@@ -0,0 +1,536 @@
+package queue
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/DavidYou21st/go-tools/file"
+)
+
+// 持久化队列，数据追加写入分段文件，消费位置保存在 offset 文件中，重启后可以继续消费
+// 记录格式：4 字节长度 | 4 字节 CRC32 | 数据
+// 投递语义为至少一次：消费位置按照同步策略落盘，崩溃后最后一批已取出的记录可能被再次取出
+
+const (
+	segmentSuffix  = ".seg"
+	offsetFileName = "consumer.offset"
+	recordHeadSize = 8
+
+	defaultMaxSegmentSize = 64 * 1024 * 1024
+	defaultMaxRecordSize  = 16 * 1024 * 1024
+)
+
+// ErrCorrupt 读取到校验失败的记录
+var ErrCorrupt = errors.New("queue: corrupt record")
+
+// SyncPolicy 刷盘策略
+type SyncPolicy int
+
+const (
+	SyncNever  SyncPolicy = iota // 由操作系统决定何时刷盘，只在轮转分段和关闭时刷盘
+	SyncAlways                   // 每次写入和读取后刷盘
+	SyncBatch                    // 每 SyncEvery 次操作刷盘一次
+)
+
+type DiskQueueOptions struct {
+	MaxSegmentSize int64 // 单个分段文件的最大字节数，默认 64MB
+	MaxRecordSize  int   // 单条记录的最大字节数，默认 16MB
+	SyncPolicy     SyncPolicy
+	SyncEvery      int // SyncBatch 时每多少次操作刷盘一次
+}
+
+type DiskQueue struct {
+	mutex    sync.Mutex
+	dir      string
+	opts     DiskQueueOptions
+	notEmpty signal
+	closed   bool
+
+	writer   *os.File
+	writeSeg int64
+	writePos int64
+
+	reader  *os.File
+	readSeg int64
+	readPos int64
+
+	count          int // 未消费的记录数
+	unsyncedWrites int
+	unsyncedReads  int
+}
+
+// OpenDiskQueue opens or creates the queue stored in dir,
+// a torn or corrupt tail left by a crash is truncated
+func OpenDiskQueue(dir string, opts DiskQueueOptions) (*DiskQueue, error) {
+	if opts.MaxSegmentSize <= 0 {
+		opts.MaxSegmentSize = defaultMaxSegmentSize
+	}
+	if opts.MaxRecordSize <= 0 {
+		opts.MaxRecordSize = defaultMaxRecordSize
+	}
+	if opts.SyncEvery <= 0 {
+		opts.SyncEvery = 1
+	}
+	if !file.IsDir(dir) {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+	}
+
+	q := &DiskQueue{dir: dir, opts: opts}
+	if err := q.recover(); err != nil {
+		q.closeFiles()
+		return nil, err
+	}
+	return q, nil
+}
+
+func (q *DiskQueue) segmentPath(seg int64) string {
+	return filepath.Join(q.dir, fmt.Sprintf("%020d%s", seg, segmentSuffix))
+}
+
+// segments 按编号升序返回目录中的分段
+func (q *DiskQueue) segments() ([]int64, error) {
+	names, err := file.GetFileListBySuffix(q.dir, segmentSuffix)
+	if err != nil {
+		return nil, err
+	}
+	segs := make([]int64, 0, len(names))
+	for _, name := range names {
+		seg, err := strconv.ParseInt(strings.TrimSuffix(name, segmentSuffix), 10, 64)
+		if err != nil {
+			continue
+		}
+		segs = append(segs, seg)
+	}
+	sort.Slice(segs, func(i, j int) bool { return segs[i] < segs[j] })
+	return segs, nil
+}
+
+func (q *DiskQueue) recover() error {
+	segs, err := q.segments()
+	if err != nil {
+		return err
+	}
+	if err = q.loadOffset(); err != nil {
+		return err
+	}
+
+	// 删除已经消费完的分段，消费位置指向的分段不存在时从最早的分段开始
+	for len(segs) > 0 && segs[0] < q.readSeg {
+		if err = os.Remove(q.segmentPath(segs[0])); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		segs = segs[1:]
+	}
+	if len(segs) == 0 {
+		segs = []int64{q.readSeg}
+	} else if segs[0] > q.readSeg {
+		q.readSeg, q.readPos = segs[0], 0
+	}
+
+	// 校验所有未消费的记录，遇到损坏的记录时截断该分段
+	for _, seg := range segs {
+		start := int64(0)
+		if seg == q.readSeg {
+			start = q.readPos
+		}
+		n, end, err := q.scanSegment(seg, start)
+		if err != nil {
+			return err
+		}
+		if seg == q.readSeg && end < q.readPos {
+			q.readPos = end
+		}
+		q.count += n
+	}
+
+	q.writeSeg = segs[len(segs)-1]
+	if q.writer, err = os.OpenFile(q.segmentPath(q.writeSeg), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644); err != nil {
+		return err
+	}
+	info, err := q.writer.Stat()
+	if err != nil {
+		return err
+	}
+	q.writePos = info.Size()
+
+	return q.openReader()
+}
+
+// scanSegment 从 start 开始校验分段中的记录，返回有效记录数和有效数据的结束位置，并截断之后的数据
+func (q *DiskQueue) scanSegment(seg, start int64) (int, int64, error) {
+	f, err := os.OpenFile(q.segmentPath(seg), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return 0, 0, err
+	}
+	if start > info.Size() {
+		start = info.Size()
+	}
+	if _, err = f.Seek(start, io.SeekStart); err != nil {
+		return 0, 0, err
+	}
+
+	n, pos := 0, start
+	for {
+		size, err := q.readRecord(f, nil)
+		if err != nil {
+			break
+		}
+		n++
+		pos += recordHeadSize + int64(size)
+	}
+	if pos < info.Size() {
+		if err = f.Truncate(pos); err != nil {
+			return 0, 0, err
+		}
+		if err = f.Sync(); err != nil {
+			return 0, 0, err
+		}
+	}
+	return n, pos, nil
+}
+
+// readRecord 从 r 读取一条记录并校验，buf 为 nil 时只返回记录长度
+func (q *DiskQueue) readRecord(r io.Reader, buf *[]byte) (int, error) {
+	var head [recordHeadSize]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		return 0, err
+	}
+	size := binary.BigEndian.Uint32(head[:4])
+	if int64(size) > int64(q.opts.MaxRecordSize) {
+		return 0, ErrCorrupt
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return 0, err
+	}
+	if crc32.ChecksumIEEE(data) != binary.BigEndian.Uint32(head[4:]) {
+		return 0, ErrCorrupt
+	}
+	if buf != nil {
+		*buf = data
+	}
+	return int(size), nil
+}
+
+func (q *DiskQueue) openReader() error {
+	if q.reader != nil {
+		q.reader.Close()
+	}
+	f, err := os.Open(q.segmentPath(q.readSeg))
+	if err != nil {
+		return err
+	}
+	if _, err = f.Seek(q.readPos, io.SeekStart); err != nil {
+		f.Close()
+		return err
+	}
+	q.reader = f
+	return nil
+}
+
+// loadOffset 读取消费位置，文件不存在时从头开始
+func (q *DiskQueue) loadOffset() error {
+	data, err := os.ReadFile(filepath.Join(q.dir, offsetFileName))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(data) != 20 || crc32.ChecksumIEEE(data[:16]) != binary.BigEndian.Uint32(data[16:]) {
+		return fmt.Errorf("queue: corrupt offset file in %s", q.dir)
+	}
+	q.readSeg = int64(binary.BigEndian.Uint64(data[:8]))
+	q.readPos = int64(binary.BigEndian.Uint64(data[8:16]))
+	return nil
+}
+
+// saveOffset 先写临时文件再重命名，保证 offset 文件总是完整的
+func (q *DiskQueue) saveOffset(sync bool) error {
+	var data [20]byte
+	binary.BigEndian.PutUint64(data[:8], uint64(q.readSeg))
+	binary.BigEndian.PutUint64(data[8:16], uint64(q.readPos))
+	binary.BigEndian.PutUint32(data[16:], crc32.ChecksumIEEE(data[:16]))
+
+	name := filepath.Join(q.dir, offsetFileName)
+	f, err := os.OpenFile(name+".tmp", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(data[:]); err == nil && sync {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(name+".tmp", name)
+}
+
+// Len returns the number of records not consumed yet
+func (q *DiskQueue) Len() int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return q.count
+}
+
+// Put appends data to the queue
+func (q *DiskQueue) Put(data []byte) error {
+	if len(data) > q.opts.MaxRecordSize {
+		return fmt.Errorf("queue: record of %d bytes exceeds the limit of %d", len(data), q.opts.MaxRecordSize)
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if q.closed {
+		return ErrClosed
+	}
+
+	size := int64(recordHeadSize + len(data))
+	if q.writePos > 0 && q.writePos+size > q.opts.MaxSegmentSize {
+		if err := q.rotate(); err != nil {
+			return err
+		}
+	}
+
+	record := make([]byte, size)
+	binary.BigEndian.PutUint32(record[:4], uint32(len(data)))
+	binary.BigEndian.PutUint32(record[4:8], crc32.ChecksumIEEE(data))
+	copy(record[recordHeadSize:], data)
+	if _, err := q.writer.Write(record); err != nil {
+		// 写入失败（例如磁盘已满）时可能已经写入了部分数据，截断掉以免之后的记录追加在残缺数据之后
+		if truncErr := q.truncateTail(); truncErr != nil {
+			return errors.Join(err, truncErr)
+		}
+		return err
+	}
+	q.writePos += size
+	q.count++
+	q.notEmpty.broadcast()
+
+	q.unsyncedWrites++
+	if q.opts.SyncPolicy == SyncAlways || (q.opts.SyncPolicy == SyncBatch && q.unsyncedWrites >= q.opts.SyncEvery) {
+		q.unsyncedWrites = 0
+		return q.writer.Sync()
+	}
+	return nil
+}
+
+// truncateTail 将写入分段截断到 writePos，丢弃之后未完整写入的数据
+func (q *DiskQueue) truncateTail() error {
+	if err := q.writer.Truncate(q.writePos); err != nil {
+		return err
+	}
+	return q.writer.Sync()
+}
+
+// rotate 关闭当前分段，创建新的分段
+func (q *DiskQueue) rotate() error {
+	if err := q.writer.Sync(); err != nil {
+		return err
+	}
+	if err := q.writer.Close(); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(q.segmentPath(q.writeSeg+1), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	q.writer = f
+	q.writeSeg++
+	q.writePos = 0
+	q.unsyncedWrites = 0
+	return nil
+}
+
+// TryTake removes the oldest record, ErrEmpty is returned if there is none
+func (q *DiskQueue) TryTake() ([]byte, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if q.closed {
+		return nil, ErrClosed
+	}
+	return q.take()
+}
+
+// Take removes the oldest record, waiting for one until ctx is done
+func (q *DiskQueue) Take(ctx context.Context) ([]byte, error) {
+	for {
+		q.mutex.Lock()
+		if q.closed {
+			q.mutex.Unlock()
+			return nil, ErrClosed
+		}
+		if q.count > 0 {
+			data, err := q.take()
+			q.mutex.Unlock()
+			return data, err
+		}
+		wait := q.notEmpty.wait()
+		q.mutex.Unlock()
+
+		select {
+		case <-wait:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// take 调用方需持有锁
+func (q *DiskQueue) take() ([]byte, error) {
+	if q.count == 0 {
+		return nil, ErrEmpty
+	}
+
+	for q.readSeg < q.writeSeg && q.readPos >= q.segmentSize(q.readSeg) {
+		if err := q.nextSegment(); err != nil {
+			return nil, err
+		}
+	}
+
+	var data []byte
+	size, err := q.readRecord(q.reader, &data)
+	if err != nil {
+		if err == ErrCorrupt || err == io.EOF || err == io.ErrUnexpectedEOF {
+			if err = q.skipCorrupt(); err != nil {
+				return nil, err
+			}
+			return nil, ErrCorrupt
+		}
+		// 其他读取错误时回到记录开头，避免读取位置错乱
+		if _, seekErr := q.reader.Seek(q.readPos, io.SeekStart); seekErr != nil {
+			return nil, errors.Join(err, seekErr)
+		}
+		return nil, err
+	}
+	q.readPos += recordHeadSize + int64(size)
+	q.count--
+
+	q.unsyncedReads++
+	if q.opts.SyncPolicy == SyncAlways || (q.opts.SyncPolicy == SyncBatch && q.unsyncedReads >= q.opts.SyncEvery) {
+		q.unsyncedReads = 0
+		if err = q.saveOffset(true); err != nil {
+			return data, err
+		}
+	}
+	return data, nil
+}
+
+// skipCorrupt 与启动时的恢复一样处理运行时读到的损坏记录：
+// 丢弃当前分段中损坏位置之后的数据，当前分段不是写入分段时直接进入下一个分段，然后重新统计剩余的有效记录数
+func (q *DiskQueue) skipCorrupt() error {
+	if q.readSeg < q.writeSeg {
+		if err := q.nextSegment(); err != nil {
+			return err
+		}
+	} else {
+		if err := q.writer.Truncate(q.readPos); err != nil {
+			return err
+		}
+		q.writePos = q.readPos
+		if _, err := q.reader.Seek(q.readPos, io.SeekStart); err != nil {
+			return err
+		}
+	}
+
+	count := 0
+	for seg := q.readSeg; seg <= q.writeSeg; seg++ {
+		start := int64(0)
+		if seg == q.readSeg {
+			start = q.readPos
+		}
+		n, end, err := q.scanSegment(seg, start)
+		if err != nil {
+			return err
+		}
+		count += n
+		if seg == q.writeSeg {
+			q.writePos = end
+		}
+	}
+	q.count = count
+	return nil
+}
+
+func (q *DiskQueue) segmentSize(seg int64) int64 {
+	info, err := os.Stat(q.segmentPath(seg))
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
+// nextSegment 当前分段已消费完，保存消费位置后删除该分段
+func (q *DiskQueue) nextSegment() error {
+	old := q.readSeg
+	q.readSeg, q.readPos = old+1, 0
+	if err := q.saveOffset(q.opts.SyncPolicy != SyncNever); err != nil {
+		q.readSeg = old
+		return err
+	}
+	if err := q.openReader(); err != nil {
+		return err
+	}
+	return os.Remove(q.segmentPath(old))
+}
+
+// Sync flushes written records and the consumer offset to disk
+func (q *DiskQueue) Sync() error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if q.closed {
+		return ErrClosed
+	}
+	return q.sync()
+}
+
+func (q *DiskQueue) sync() error {
+	if err := q.writer.Sync(); err != nil {
+		return err
+	}
+	q.unsyncedWrites, q.unsyncedReads = 0, 0
+	return q.saveOffset(true)
+}
+
+// Close flushes the queue to disk and releases its files, waiting consumers get ErrClosed
+func (q *DiskQueue) Close() error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if q.closed {
+		return nil
+	}
+	q.closed = true
+	q.notEmpty.broadcast()
+	err := q.sync()
+	q.closeFiles()
+	return err
+}
+
+func (q *DiskQueue) closeFiles() {
+	if q.writer != nil {
+		q.writer.Close()
+	}
+	if q.reader != nil {
+		q.reader.Close()
+	}
+}
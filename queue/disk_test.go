@@ -0,0 +1,182 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+func openTestDiskQueue(t *testing.T, dir string, opts DiskQueueOptions) *DiskQueue {
+	t.Helper()
+	q, err := OpenDiskQueue(dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return q
+}
+
+func expectTake(t *testing.T, q *DiskQueue, want string) {
+	t.Helper()
+	data, err := q.TryTake()
+	if err != nil || string(data) != want {
+		t.Fatalf("TryTake: got %q %v, want %q", data, err, want)
+	}
+}
+
+// corrupt 翻转分段文件中第 offset 个字节
+func corrupt(t *testing.T, path string, offset int64) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_RDWR, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	b := make([]byte, 1)
+	if _, err = f.ReadAt(b, offset); err != nil {
+		t.Fatal(err)
+	}
+	b[0] ^= 0xff
+	if _, err = f.WriteAt(b, offset); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDiskQueueReopen(t *testing.T) {
+	dir := t.TempDir()
+	q := openTestDiskQueue(t, dir, DiskQueueOptions{MaxSegmentSize: 64})
+	for i := 0; i < 20; i++ {
+		if err := q.Put([]byte(fmt.Sprintf("record-%02d", i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 5; i++ {
+		expectTake(t, q, fmt.Sprintf("record-%02d", i))
+	}
+	if err := q.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	q = openTestDiskQueue(t, dir, DiskQueueOptions{MaxSegmentSize: 64})
+	defer q.Close()
+	if q.Len() != 15 {
+		t.Fatalf("Len after reopen: got %d, want 15", q.Len())
+	}
+	for i := 5; i < 20; i++ {
+		expectTake(t, q, fmt.Sprintf("record-%02d", i))
+	}
+	if _, err := q.TryTake(); err != ErrEmpty {
+		t.Fatalf("TryTake on an empty queue: got %v, want ErrEmpty", err)
+	}
+	// 已消费完的分段被删除，只剩写入分段
+	if segs, _ := q.segments(); len(segs) != 1 {
+		t.Fatalf("consumed segments were not removed: %v", segs)
+	}
+}
+
+func TestDiskQueueTakeWaits(t *testing.T) {
+	q := openTestDiskQueue(t, t.TempDir(), DiskQueueOptions{})
+	defer q.Close()
+
+	result := make(chan []byte, 1)
+	go func() {
+		data, err := q.Take(context.Background())
+		if err != nil {
+			t.Error(err)
+		}
+		result <- data
+	}()
+	time.Sleep(10 * time.Millisecond)
+	_ = q.Put([]byte("hello"))
+	if data := <-result; string(data) != "hello" {
+		t.Fatalf("got %q, want hello", data)
+	}
+}
+
+func TestDiskQueueCorruptTailOnOpen(t *testing.T) {
+	dir := t.TempDir()
+	q := openTestDiskQueue(t, dir, DiskQueueOptions{})
+	_ = q.Put([]byte("first"))
+	_ = q.Put([]byte("second"))
+	_ = q.Close()
+
+	// 模拟崩溃时写了一半的记录
+	f, _ := os.OpenFile(q.segmentPath(0), os.O_WRONLY|os.O_APPEND, 0644)
+	_, _ = f.Write([]byte{0, 0, 0, 10, 1, 2})
+	f.Close()
+
+	q = openTestDiskQueue(t, dir, DiskQueueOptions{})
+	defer q.Close()
+	if q.Len() != 2 {
+		t.Fatalf("Len: got %d, want 2", q.Len())
+	}
+	_ = q.Put([]byte("third"))
+	expectTake(t, q, "first")
+	expectTake(t, q, "second")
+	expectTake(t, q, "third")
+}
+
+func TestDiskQueueCorruptRecordInWriteSegment(t *testing.T) {
+	q := openTestDiskQueue(t, t.TempDir(), DiskQueueOptions{})
+	defer q.Close()
+	_ = q.Put([]byte("first"))
+	_ = q.Put([]byte("second"))
+	_ = q.Put([]byte("third"))
+
+	// 破坏第二条记录的数据
+	corrupt(t, q.segmentPath(0), int64(recordHeadSize+len("first")+recordHeadSize))
+
+	expectTake(t, q, "first")
+	if _, err := q.TryTake(); err != ErrCorrupt {
+		t.Fatalf("TryTake of the corrupt record: got %v, want ErrCorrupt", err)
+	}
+	// 损坏位置之后的数据被丢弃，队列恢复可用
+	if q.Len() != 0 {
+		t.Fatalf("Len after corruption: got %d, want 0", q.Len())
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := q.Take(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("Take on an empty queue: got %v, want DeadlineExceeded", err)
+	}
+	_ = q.Put([]byte("fourth"))
+	expectTake(t, q, "fourth")
+}
+
+func TestDiskQueueCorruptRecordSkipsSegment(t *testing.T) {
+	// 每条记录单独一个分段
+	q := openTestDiskQueue(t, t.TempDir(), DiskQueueOptions{MaxSegmentSize: 1})
+	defer q.Close()
+	for _, s := range []string{"a", "b", "c"} {
+		_ = q.Put([]byte(s))
+	}
+	corrupt(t, q.segmentPath(1), recordHeadSize)
+
+	expectTake(t, q, "a")
+	if _, err := q.TryTake(); err != ErrCorrupt {
+		t.Fatalf("TryTake of the corrupt record: got %v, want ErrCorrupt", err)
+	}
+	if q.Len() != 1 {
+		t.Fatalf("Len after corruption: got %d, want 1", q.Len())
+	}
+	expectTake(t, q, "c")
+}
+
+func TestDiskQueueTruncateTailAfterFailedWrite(t *testing.T) {
+	q := openTestDiskQueue(t, t.TempDir(), DiskQueueOptions{})
+	defer q.Close()
+	_ = q.Put([]byte("first"))
+
+	// 模拟写入失败时留下的半条记录
+	f, _ := os.OpenFile(q.segmentPath(0), os.O_WRONLY|os.O_APPEND, 0644)
+	_, _ = f.Write([]byte{0, 0, 0, 10, 1, 2})
+	f.Close()
+	if err := q.truncateTail(); err != nil {
+		t.Fatal(err)
+	}
+
+	_ = q.Put([]byte("second"))
+	expectTake(t, q, "first")
+	expectTake(t, q, "second")
+}
@@ -0,0 +1,9 @@
+package queue
+
+import "errors"
+
+var (
+	ErrFull   = errors.New("queue is full")
+	ErrEmpty  = errors.New("queue is empty")
+	ErrClosed = errors.New("queue is closed")
+)
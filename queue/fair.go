@@ -0,0 +1,322 @@
+package queue
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// 公平队列，按 key（租户、用户、连接等）分区，出队时在有数据的分区之间轮转，
+// 避免单个热点 key 占满消费者
+
+// FairPolicy 分区之间的调度策略
+type FairPolicy int
+
+const (
+	WeightedRoundRobin FairPolicy = iota // 每轮从每个分区连续取出 Weight 个元素
+	DeficitRoundRobin                    // 每轮给每个分区增加 Quantum*Weight 的额度，按元素的 Cost 扣减
+)
+
+type FairQueueOptions[K comparable, T any] struct {
+	Policy            FairPolicy
+	PartitionCapacity int         // 单个分区的容量，0 表示不限制
+	Key               func(T) K   // Put 时计算元素所属的分区，为 nil 时所有元素属于零值 key 的分区
+	Weight            func(K) int // 分区的权重，默认为 1
+	Cost              func(T) int // DeficitRoundRobin 时元素的成本，默认为 1
+	Quantum           int         // DeficitRoundRobin 时每单位权重每轮增加的额度，默认为 1
+}
+
+type fairPartition[K comparable, T any] struct {
+	key      K
+	items    *LoopQueue[T]
+	weight   int
+	served   int  // 本轮已取出的元素数（WRR）
+	deficit  int  // 剩余额度（DRR）
+	credited bool // 本轮是否已经增加过额度（DRR）
+	node     *LinkedNode[*fairPartition[K, T]]
+	enqueued *LoopQueue[time.Time] // 设置 hook 时记录每个元素的入队时间
+}
+
+type FairQueue[K comparable, T any] struct {
+	mutex      sync.Mutex
+	opts       FairQueueOptions[K, T]
+	partitions map[K]*fairPartition[K, T]
+	active     LinkedList[*fairPartition[K, T]] // 有数据的分区，队头为下一个被调度的分区
+	len        int
+	notEmpty   signal
+	notFull    signal
+	closed     bool
+	hook       Hook[T]
+}
+
+var _ Backlog[int] = (*FairQueue[int, int])(nil)
+
+func NewFairQueue[K comparable, T any](opts FairQueueOptions[K, T]) *FairQueue[K, T] {
+	if opts.Weight == nil {
+		opts.Weight = func(K) int { return 1 }
+	}
+	if opts.Cost == nil {
+		opts.Cost = func(T) int { return 1 }
+	}
+	if opts.Quantum <= 0 {
+		opts.Quantum = 1
+	}
+	return &FairQueue[K, T]{
+		opts:       opts,
+		partitions: make(map[K]*fairPartition[K, T]),
+	}
+}
+
+// SetHook reports the events of the queue to hook, a nil hook removes it.
+// Elements already in the queue are considered enqueued now, depth is the length of the whole queue
+func (q *FairQueue[K, T]) SetHook(hook Hook[T]) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.hook = hook
+	for _, p := range q.partitions {
+		p.enqueued = nil
+		if hook != nil {
+			p.enqueued = newEnqueueTimes(p.items.Len())
+		}
+	}
+}
+
+// Len returns the number of elements in all partitions
+func (q *FairQueue[K, T]) Len() int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return q.len
+}
+
+// PartitionLen returns the number of elements in the partition of key
+func (q *FairQueue[K, T]) PartitionLen(key K) int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if p, ok := q.partitions[key]; ok {
+		return p.items.Len()
+	}
+	return 0
+}
+
+// Put adds v to the partition given by the Key option, see PutKey
+func (q *FairQueue[K, T]) Put(ctx context.Context, v T) error {
+	var key K
+	if q.opts.Key != nil {
+		key = q.opts.Key(v)
+	}
+	return q.PutKey(ctx, key, v)
+}
+
+// PutKey adds v to the partition of key, waiting for space in that partition until ctx is done
+func (q *FairQueue[K, T]) PutKey(ctx context.Context, key K, v T) error {
+	for {
+		q.mutex.Lock()
+		hook := q.hook
+		if q.closed {
+			q.mutex.Unlock()
+			if hook != nil {
+				hook.OnDrop(v, ErrClosed)
+			}
+			return ErrClosed
+		}
+		if q.push(key, v) {
+			depth := q.len
+			q.mutex.Unlock()
+			if hook != nil {
+				hook.OnEnqueue(v, depth)
+			}
+			return nil
+		}
+		wait := q.notFull.wait()
+		q.mutex.Unlock()
+
+		select {
+		case <-wait:
+		case <-ctx.Done():
+			if hook != nil {
+				hook.OnDrop(v, ctx.Err())
+			}
+			return ctx.Err()
+		}
+	}
+}
+
+// TryPutKey adds v to the partition of key, ErrFull is returned if the partition is full
+func (q *FairQueue[K, T]) TryPutKey(key K, v T) error {
+	q.mutex.Lock()
+	hook := q.hook
+	var err error
+	if q.closed {
+		err = ErrClosed
+	} else if !q.push(key, v) {
+		err = ErrFull
+	}
+	depth := q.len
+	q.mutex.Unlock()
+
+	if hook != nil {
+		if err != nil {
+			hook.OnDrop(v, err)
+		} else {
+			hook.OnEnqueue(v, depth)
+		}
+	}
+	return err
+}
+
+// push 调用方需持有锁，分区已满时返回 false
+func (q *FairQueue[K, T]) push(key K, v T) bool {
+	p, ok := q.partitions[key]
+	if !ok {
+		p = &fairPartition[K, T]{key: key, weight: q.opts.Weight(key)}
+		if p.weight < 1 {
+			p.weight = 1
+		}
+		if q.opts.PartitionCapacity > 0 {
+			p.items = NewLoopQueue[T](q.opts.PartitionCapacity)
+		} else {
+			p.items = NewGrowableLoopQueue[T](minGrowCap)
+		}
+		if q.hook != nil {
+			p.enqueued = newEnqueueTimes(0)
+		}
+		q.partitions[key] = p
+	}
+	if p.items.Push(v) != nil {
+		return false
+	}
+	pushEnqueueTime(p.enqueued)
+	if p.node == nil {
+		p.node = q.active.PushBack(p)
+	}
+	q.len++
+	q.notEmpty.broadcast()
+	return true
+}
+
+// Take removes the next element according to the scheduling policy, waiting for one until ctx is done.
+// Once the queue is closed the remaining elements can still be taken, then ErrClosed is returned
+func (q *FairQueue[K, T]) Take(ctx context.Context) (T, error) {
+	_, v, err := q.TakeKey(ctx)
+	return v, err
+}
+
+// TakeKey is like Take but also returns the partition key of the element
+func (q *FairQueue[K, T]) TakeKey(ctx context.Context) (K, T, error) {
+	for {
+		q.mutex.Lock()
+		if q.len > 0 {
+			k, v, wait := q.pop()
+			hook, depth := q.hook, q.len
+			q.mutex.Unlock()
+			if hook != nil {
+				hook.OnDequeue(v, wait, depth)
+			}
+			return k, v, nil
+		}
+		if q.closed {
+			q.mutex.Unlock()
+			var (
+				k K
+				v T
+			)
+			return k, v, ErrClosed
+		}
+		wait := q.notEmpty.wait()
+		q.mutex.Unlock()
+
+		select {
+		case <-wait:
+		case <-ctx.Done():
+			var (
+				k K
+				v T
+			)
+			return k, v, ctx.Err()
+		}
+	}
+}
+
+// pop 调用方需持有锁且队列不为空，返回元素所属的分区、元素和它的等待时间
+func (q *FairQueue[K, T]) pop() (K, T, time.Duration) {
+	skipped := 0
+	for {
+		p := q.active.Front().Value
+		if q.opts.Policy == DeficitRoundRobin {
+			if !p.credited {
+				p.deficit += q.opts.Quantum * p.weight
+				p.credited = true
+			}
+			cost := q.headCost(p)
+			if cost > p.deficit {
+				// 额度不足，轮到下一个分区
+				p.credited = false
+				q.active.MoveToBack(p.node)
+				if skipped++; skipped >= q.active.Len() {
+					q.skipRounds()
+					skipped = 0
+				}
+				continue
+			}
+			p.deficit -= cost
+		}
+
+		v, _ := p.items.Pop()
+		wait := popWait(p.enqueued)
+		q.len--
+		q.notFull.broadcast()
+
+		p.served++
+		if p.items.IsEmpty() {
+			q.active.Remove(p.node)
+			delete(q.partitions, p.key)
+		} else if q.opts.Policy == WeightedRoundRobin && p.served >= p.weight {
+			p.served = 0
+			q.active.MoveToBack(p.node)
+		}
+		return p.key, v, wait
+	}
+}
+
+// headCost 返回分区队头元素的成本，小于 1 的成本按 1 计算
+func (q *FairQueue[K, T]) headCost(p *fairPartition[K, T]) int {
+	head, _ := p.items.Peek()
+	if cost := q.opts.Cost(head); cost > 1 {
+		return cost
+	}
+	return 1
+}
+
+// skipRounds 在一整轮中没有分区能取出元素时调用，直接补足中间各轮的额度，
+// 避免元素成本远大于 Quantum 时逐轮循环。最后一轮仍按轮转顺序进行，保证调度结果不变
+func (q *FairQueue[K, T]) skipRounds() {
+	rounds := 0
+	q.active.Range(func(p *fairPartition[K, T]) bool {
+		quantum := q.opts.Quantum * p.weight
+		need := (q.headCost(p) - p.deficit + quantum - 1) / quantum
+		if rounds == 0 || need < rounds {
+			rounds = need
+		}
+		return true
+	})
+	if rounds <= 1 {
+		return
+	}
+	q.active.Range(func(p *fairPartition[K, T]) bool {
+		p.deficit += (rounds - 1) * q.opts.Quantum * p.weight
+		return true
+	})
+}
+
+// Close wakes up all waiting producers and consumers, further Put calls fail with ErrClosed
+// while consumers can drain the remaining elements
+func (q *FairQueue[K, T]) Close() {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if q.closed {
+		return
+	}
+	q.closed = true
+	q.notEmpty.broadcast()
+	q.notFull.broadcast()
+}
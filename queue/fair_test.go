@@ -0,0 +1,194 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+)
+
+type tenantJob struct {
+	tenant string
+	id     string
+	cost   int
+}
+
+func takeIds(t *testing.T, q *FairQueue[string, tenantJob], n int) []string {
+	t.Helper()
+	ids := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		job, err := q.Take(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, job.id)
+	}
+	return ids
+}
+
+func expectIds(t *testing.T, got []string, want ...string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestFairQueueWeightedRoundRobin(t *testing.T) {
+	ctx := context.Background()
+	q := NewFairQueue(FairQueueOptions[string, tenantJob]{
+		Key: func(j tenantJob) string { return j.tenant },
+		Weight: func(tenant string) int {
+			if tenant == "a" {
+				return 2
+			}
+			return 1
+		},
+	})
+	for _, id := range []string{"a1", "a2", "a3", "a4"} {
+		_ = q.Put(ctx, tenantJob{tenant: "a", id: id})
+	}
+	for _, id := range []string{"b1", "b2", "b3"} {
+		_ = q.Put(ctx, tenantJob{tenant: "b", id: id})
+	}
+	if q.PartitionLen("a") != 4 || q.Len() != 7 {
+		t.Fatalf("PartitionLen: got %d, Len: got %d", q.PartitionLen("a"), q.Len())
+	}
+	expectIds(t, takeIds(t, q, 7), "a1", "a2", "b1", "a3", "a4", "b2", "b3")
+}
+
+func TestFairQueueDeficitRoundRobin(t *testing.T) {
+	ctx := context.Background()
+	q := NewFairQueue(FairQueueOptions[string, tenantJob]{
+		Policy:  DeficitRoundRobin,
+		Key:     func(j tenantJob) string { return j.tenant },
+		Cost:    func(j tenantJob) int { return j.cost },
+		Quantum: 2,
+	})
+	_ = q.Put(ctx, tenantJob{tenant: "a", id: "a1", cost: 2})
+	_ = q.Put(ctx, tenantJob{tenant: "a", id: "a2", cost: 2})
+	for _, id := range []string{"b1", "b2", "b3"} {
+		_ = q.Put(ctx, tenantJob{tenant: "b", id: id, cost: 1})
+	}
+	// 每轮额度为 2：a 取出一个成本为 2 的元素，b 取出两个成本为 1 的元素
+	expectIds(t, takeIds(t, q, 5), "a1", "b1", "b2", "a2", "b3")
+}
+
+// drrOrder 逐轮模拟赤字轮转调度，作为 FairQueue 的参照实现，分区按首次出现的顺序轮转
+func drrOrder(jobs []tenantJob, quantum int) []string {
+	var tenants []string
+	pending := make(map[string][]tenantJob)
+	for _, j := range jobs {
+		if len(pending[j.tenant]) == 0 {
+			tenants = append(tenants, j.tenant)
+		}
+		pending[j.tenant] = append(pending[j.tenant], j)
+	}
+	deficit := make(map[string]int)
+	var order []string
+	for len(order) < len(jobs) {
+		for _, tenant := range tenants {
+			if len(pending[tenant]) == 0 {
+				continue
+			}
+			deficit[tenant] += quantum
+			for len(pending[tenant]) > 0 && pending[tenant][0].cost <= deficit[tenant] {
+				deficit[tenant] -= pending[tenant][0].cost
+				order = append(order, pending[tenant][0].id)
+				pending[tenant] = pending[tenant][1:]
+			}
+			if len(pending[tenant]) == 0 {
+				deficit[tenant] = 0
+			}
+		}
+	}
+	return order
+}
+
+func TestFairQueueDeficitRoundRobinLargeCost(t *testing.T) {
+	ctx := context.Background()
+	r := rand.New(rand.NewSource(1))
+	tenants := []string{"a", "b", "c"}
+	var jobs []tenantJob
+	for i := 0; i < 60; i++ {
+		tenant := tenants[r.Intn(len(tenants))]
+		jobs = append(jobs, tenantJob{tenant: tenant, id: fmt.Sprint(tenant, i), cost: 1 + r.Intn(50)})
+	}
+	q := NewFairQueue(FairQueueOptions[string, tenantJob]{
+		Policy:  DeficitRoundRobin,
+		Key:     func(j tenantJob) string { return j.tenant },
+		Cost:    func(j tenantJob) int { return j.cost },
+		Quantum: 3,
+	})
+	for _, j := range jobs {
+		_ = q.Put(ctx, j)
+	}
+	expectIds(t, takeIds(t, q, len(jobs)), drrOrder(jobs, 3)...)
+
+	// 成本远大于额度时不逐轮循环，成本小于 1 时按 1 计算
+	_ = q.Put(ctx, tenantJob{tenant: "a", id: "huge", cost: math.MaxInt32})
+	_ = q.Put(ctx, tenantJob{tenant: "b", id: "free", cost: -5})
+	expectIds(t, takeIds(t, q, 2), "free", "huge")
+}
+
+func TestFairQueuePartitionCapacity(t *testing.T) {
+	ctx := context.Background()
+	q := NewFairQueue(FairQueueOptions[string, int]{PartitionCapacity: 1})
+	if err := q.TryPutKey("a", 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.TryPutKey("a", 2); err != ErrFull {
+		t.Fatalf("TryPutKey on a full partition: got %v, want ErrFull", err)
+	}
+	// 其他分区不受影响
+	if err := q.TryPutKey("b", 3); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- q.PutKey(ctx, "a", 2)
+	}()
+	select {
+	case err := <-done:
+		t.Fatalf("PutKey returned %v on a full partition", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+	if k, v, _ := q.TakeKey(ctx); k != "a" || v != 1 {
+		t.Fatalf("TakeKey: got %s %d, want a 1", k, v)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFairQueueClose(t *testing.T) {
+	ctx := context.Background()
+	q := NewFairQueue(FairQueueOptions[string, int]{})
+	_ = q.PutKey(ctx, "a", 1)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if v, err := q.Take(ctx); err != nil || v != 1 {
+			t.Errorf("Take: got %d %v, want 1", v, err)
+		}
+		if _, err := q.Take(ctx); err != ErrClosed {
+			t.Errorf("Take on a closed empty queue: got %v, want ErrClosed", err)
+		}
+	}()
+	time.Sleep(10 * time.Millisecond)
+	q.Close()
+	wg.Wait()
+	if err := q.PutKey(ctx, "a", 2); err != ErrClosed {
+		t.Fatalf("PutKey after Close: got %v, want ErrClosed", err)
+	}
+}
@@ -0,0 +1,138 @@
+package queue
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// 队列事件的钩子，用于导出队列深度、吞吐量和等待时间等指标
+// LoopQueue、SyncLoopQueue、BlockingQueue、BlockingPriorityQueue、DelayQueue 和 FairQueue 通过 SetHook 设置钩子
+
+// Hook 队列事件的回调，调用时不持有队列的锁，但可能被多个协程并发调用
+type Hook[T any] interface {
+	OnEnqueue(v T, depth int)                     // 元素入队后调用，depth 为入队后的队列长度
+	OnDequeue(v T, wait time.Duration, depth int) // 元素出队后调用，wait 为元素在队列中停留的时间
+	OnDrop(v T, err error)                        // 元素未能入队时调用，err 为 Put 返回的错误
+}
+
+// HookFuncs 用函数实现 Hook，为 nil 的函数会被忽略
+type HookFuncs[T any] struct {
+	Enqueue func(v T, depth int)
+	Dequeue func(v T, wait time.Duration, depth int)
+	Drop    func(v T, err error)
+}
+
+func (h HookFuncs[T]) OnEnqueue(v T, depth int) {
+	if h.Enqueue != nil {
+		h.Enqueue(v, depth)
+	}
+}
+
+func (h HookFuncs[T]) OnDequeue(v T, wait time.Duration, depth int) {
+	if h.Dequeue != nil {
+		h.Dequeue(v, wait, depth)
+	}
+}
+
+func (h HookFuncs[T]) OnDrop(v T, err error) {
+	if h.Drop != nil {
+		h.Drop(v, err)
+	}
+}
+
+// 先进先出的队列用一个 LoopQueue 按顺序记录元素的入队时间，出队时计算等待时间
+
+// newEnqueueTimes 返回记录了 n 个当前时间的入队时间队列，用于给已有的元素设置钩子
+func newEnqueueTimes(n int) *LoopQueue[time.Time] {
+	times := NewGrowableLoopQueue[time.Time](minGrowCap)
+	resetEnqueueTimes(times, n)
+	return times
+}
+
+// resetEnqueueTimes 将入队时间重置为 n 个当前时间，times 为 nil 时不做任何事
+func resetEnqueueTimes(times *LoopQueue[time.Time], n int) {
+	if times == nil {
+		return
+	}
+	times.Clear()
+	now := time.Now()
+	for i := 0; i < n; i++ {
+		_ = times.Push(now)
+	}
+}
+
+func pushEnqueueTime(times *LoopQueue[time.Time]) {
+	if times != nil {
+		_ = times.Push(time.Now())
+	}
+}
+
+// popWait 取出最早的入队时间并返回等待时间，times 为 nil 时返回 0
+func popWait(times *LoopQueue[time.Time]) time.Duration {
+	if times == nil {
+		return 0
+	}
+	at, err := times.Pop()
+	if err != nil {
+		return 0
+	}
+	return time.Since(at)
+}
+
+// QueueMetrics 队列的统计信息
+type QueueMetrics struct {
+	Depth    int           // 最近一次事件后的队列长度
+	Enqueued uint64        // 入队的元素数
+	Dequeued uint64        // 出队的元素数
+	Dropped  uint64        // 未能入队的元素数
+	AvgWait  time.Duration // 元素在队列中的平均等待时间
+	MaxWait  time.Duration // 元素在队列中的最长等待时间
+}
+
+// MetricsHook 统计队列指标的 Hook，零值即可使用
+type MetricsHook[T any] struct {
+	depth     atomic.Int64
+	enqueued  atomic.Uint64
+	dequeued  atomic.Uint64
+	dropped   atomic.Uint64
+	waitTotal atomic.Int64
+	waitMax   atomic.Int64
+}
+
+var _ Hook[int] = (*MetricsHook[int])(nil)
+
+func (m *MetricsHook[T]) OnEnqueue(_ T, depth int) {
+	m.enqueued.Add(1)
+	m.depth.Store(int64(depth))
+}
+
+func (m *MetricsHook[T]) OnDequeue(_ T, wait time.Duration, depth int) {
+	m.dequeued.Add(1)
+	m.depth.Store(int64(depth))
+	m.waitTotal.Add(int64(wait))
+	for {
+		max := m.waitMax.Load()
+		if int64(wait) <= max || m.waitMax.CompareAndSwap(max, int64(wait)) {
+			return
+		}
+	}
+}
+
+func (m *MetricsHook[T]) OnDrop(T, error) {
+	m.dropped.Add(1)
+}
+
+// Metrics returns the current statistics
+func (m *MetricsHook[T]) Metrics() QueueMetrics {
+	metrics := QueueMetrics{
+		Depth:    int(m.depth.Load()),
+		Enqueued: m.enqueued.Load(),
+		Dequeued: m.dequeued.Load(),
+		Dropped:  m.dropped.Load(),
+		MaxWait:  time.Duration(m.waitMax.Load()),
+	}
+	if metrics.Dequeued > 0 {
+		metrics.AvgWait = time.Duration(m.waitTotal.Load() / int64(metrics.Dequeued))
+	}
+	return metrics
+}
@@ -0,0 +1,105 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// hookedQueue 不同队列类型的公共操作，用于验证它们的钩子
+type hookedQueue struct {
+	setHook func(Hook[int])
+	put     func(v int) error
+	take    func() (int, error)
+	close   func()
+}
+
+func TestQueueHooks(t *testing.T) {
+	ctx := context.Background()
+	tests := map[string]func() hookedQueue{
+		"LoopQueue": func() hookedQueue {
+			q := NewLoopQueue[int](2)
+			return hookedQueue{q.SetHook, q.Push, q.Pop, nil}
+		},
+		"SyncLoopQueue": func() hookedQueue {
+			q := NewSyncLoopQueue(NewLoopQueue[int](2))
+			return hookedQueue{q.SetHook, q.Push, q.Pop, nil}
+		},
+		"BlockingPriorityQueue": func() hookedQueue {
+			q := NewBlockingPriorityQueue(intLess)
+			return hookedQueue{
+				q.SetHook,
+				func(v int) error { return q.Put(ctx, v) },
+				func() (int, error) { return q.Take(ctx) },
+				q.Close,
+			}
+		},
+		"DelayQueue": func() hookedQueue {
+			q := NewDelayQueue[int]()
+			return hookedQueue{
+				q.SetHook,
+				func(v int) error { return q.Put("", v, 0) },
+				q.TryTake,
+				q.Close,
+			}
+		},
+		"FairQueue": func() hookedQueue {
+			q := NewFairQueue(FairQueueOptions[int, int]{PartitionCapacity: 2})
+			return hookedQueue{
+				q.SetHook,
+				func(v int) error { return q.TryPutKey(0, v) },
+				func() (int, error) { return q.Take(ctx) },
+				q.Close,
+			}
+		},
+	}
+	for name, newQueue := range tests {
+		t.Run(name, func(t *testing.T) {
+			q := newQueue()
+			if err := q.put(1); err != nil {
+				t.Fatal(err)
+			}
+			// 设置钩子之前已有的元素也会在出队时报告
+			hook := &MetricsHook[int]{}
+			q.setHook(hook)
+			if err := q.put(2); err != nil {
+				t.Fatal(err)
+			}
+			time.Sleep(10 * time.Millisecond)
+			for i := 0; i < 2; i++ {
+				if _, err := q.take(); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if q.close != nil {
+				q.close()
+				_ = q.put(3)
+			}
+
+			m := hook.Metrics()
+			if m.Enqueued != 1 || m.Dequeued != 2 || m.Depth != 0 {
+				t.Fatalf("unexpected metrics %+v", m)
+			}
+			if q.close != nil && m.Dropped != 1 {
+				t.Fatalf("Dropped: got %d, want 1", m.Dropped)
+			}
+			if m.MaxWait < 10*time.Millisecond || m.AvgWait < 10*time.Millisecond {
+				t.Fatalf("wait times too short: %+v", m)
+			}
+		})
+	}
+}
+
+func TestLoopQueueHookFull(t *testing.T) {
+	hook := &MetricsHook[int]{}
+	q := NewLoopQueue[int](1)
+	q.SetHook(hook)
+	_ = q.Push(1)
+	if err := q.Push(2); err != ErrFull {
+		t.Fatalf("Push on a full queue: got %v, want ErrFull", err)
+	}
+	q.Drain()
+	if m := hook.Metrics(); m.Enqueued != 1 || m.Dequeued != 1 || m.Dropped != 1 {
+		t.Fatalf("unexpected metrics %+v", m)
+	}
+}
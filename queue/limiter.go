@@ -0,0 +1,393 @@
+package queue
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// 限流器，令牌桶允许突发流量，漏桶按固定间隔匀速放行
+
+// Limiter 限流器
+type Limiter interface {
+	Allow() bool                    // 不等待，有配额时返回 true 并消耗配额
+	Wait(ctx context.Context) error // 等待直到获得配额或 ctx 结束
+}
+
+var (
+	_ Limiter = (*TokenBucket)(nil)
+	_ Limiter = (*LeakyBucket)(nil)
+)
+
+// sleep 使用 clock 等待 d，ctx 先结束时返回 ctx.Err()
+func sleep(ctx context.Context, clock Clock, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := clock.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// TokenBucket 令牌桶，以 rate 的速度生成令牌，最多积累 burst 个
+type TokenBucket struct {
+	mutex  sync.Mutex
+	rate   float64 // 每秒生成的令牌数
+	burst  float64
+	tokens float64 // 可以为负数，表示已被等待中的调用预定
+	last   time.Time
+	clock  Clock
+}
+
+func NewTokenBucket(rate float64, burst int) *TokenBucket {
+	return NewTokenBucketWithClock(rate, burst, SystemClock)
+}
+
+// NewTokenBucketWithClock returns a full token bucket driven by clock, burst is at least 1
+func NewTokenBucketWithClock(rate float64, burst int, clock Clock) *TokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &TokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   clock.Now(),
+		clock:  clock,
+	}
+}
+
+// refill 调用方需持有锁
+func (b *TokenBucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens += elapsed.Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+		b.last = now
+	}
+}
+
+// Tokens returns the number of tokens currently available
+func (b *TokenBucket) Tokens() float64 {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.refill(b.clock.Now())
+	return b.tokens
+}
+
+func (b *TokenBucket) Allow() bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.refill(b.clock.Now())
+	if b.tokens >= 1 {
+		b.tokens--
+		return true
+	}
+	return false
+}
+
+// Wait reserves a token and waits until it is available. If ctx is done first the token is returned,
+// if the ctx deadline is earlier than the token becomes available Wait fails immediately
+func (b *TokenBucket) Wait(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	b.mutex.Lock()
+	now := b.clock.Now()
+	b.refill(now)
+	var delay time.Duration
+	if b.tokens < 1 {
+		if b.rate <= 0 {
+			b.mutex.Unlock()
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		delay = time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+		if deadline, ok := ctx.Deadline(); ok && deadline.Sub(now) < delay {
+			b.mutex.Unlock()
+			return context.DeadlineExceeded
+		}
+	}
+	b.tokens--
+	b.mutex.Unlock()
+
+	if err := sleep(ctx, b.clock, delay); err != nil {
+		b.mutex.Lock()
+		b.tokens++
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+		b.mutex.Unlock()
+		return err
+	}
+	return nil
+}
+
+// LeakyBucket 漏桶，每 interval 放行一次，没有突发；
+// capacity 为可以排队等待的调用数，超出时 Wait 返回 ErrFull，0 表示不限制
+type LeakyBucket struct {
+	mutex    sync.Mutex
+	interval time.Duration // 为 0 时表示 rate <= 0，不放行任何调用
+	capacity int
+	next     time.Time // 下一个可以放行的时间
+	clock    Clock
+}
+
+func NewLeakyBucket(rate float64, capacity int) *LeakyBucket {
+	return NewLeakyBucketWithClock(rate, capacity, SystemClock)
+}
+
+// NewLeakyBucketWithClock returns a leaky bucket letting rate calls per second through, driven by clock.
+// A rate <= 0 lets nothing through: Allow always fails and Wait blocks until ctx is done
+func NewLeakyBucketWithClock(rate float64, capacity int, clock Clock) *LeakyBucket {
+	if capacity < 0 {
+		capacity = 0
+	}
+	var interval time.Duration
+	if rate > 0 {
+		interval = math.MaxInt64
+		if d := float64(time.Second) / rate; d < math.MaxInt64 {
+			interval = time.Duration(d)
+		}
+		if interval < 1 {
+			interval = 1
+		}
+	}
+	return &LeakyBucket{
+		interval: interval,
+		capacity: capacity,
+		next:     clock.Now(),
+		clock:    clock,
+	}
+}
+
+func (b *LeakyBucket) Allow() bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	now := b.clock.Now()
+	if b.interval == 0 || now.Before(b.next) {
+		return false
+	}
+	b.next = now.Add(b.interval)
+	return true
+}
+
+// Wait reserves the next slot and waits for it
+func (b *LeakyBucket) Wait(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if b.interval == 0 {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	b.mutex.Lock()
+	now := b.clock.Now()
+	slot := b.next
+	if slot.Before(now) {
+		slot = now
+	}
+	delay := slot.Sub(now)
+	// 使用浮点数比较，避免 capacity*interval 溢出
+	if b.capacity > 0 && float64(delay) > float64(b.capacity)*float64(b.interval) {
+		b.mutex.Unlock()
+		return ErrFull
+	}
+	if deadline, ok := ctx.Deadline(); ok && deadline.Before(slot) {
+		b.mutex.Unlock()
+		return context.DeadlineExceeded
+	}
+	b.next = slot.Add(b.interval)
+	b.mutex.Unlock()
+
+	if err := sleep(ctx, b.clock, delay); err != nil {
+		// 只有最后预定的时间段可以归还，否则会打乱后面的调用
+		b.mutex.Lock()
+		if b.next.Equal(slot.Add(b.interval)) {
+			b.next = slot
+		}
+		b.mutex.Unlock()
+		return err
+	}
+	return nil
+}
+
+// RateLimitedQueue 限速队列，Take 先取出元素再等待限流器的配额，
+// 没有元素时不消耗配额；等待配额失败的元素暂存在 pending 中，下一次 Take 优先返回
+type RateLimitedQueue[T any] struct {
+	backlog Backlog[T]
+	limiter Limiter
+	mutex   sync.Mutex
+	pending Deque[T]
+}
+
+var _ Backlog[int] = (*RateLimitedQueue[int])(nil)
+
+func NewRateLimitedQueue[T any](backlog Backlog[T], limiter Limiter) *RateLimitedQueue[T] {
+	return &RateLimitedQueue[T]{backlog: backlog, limiter: limiter}
+}
+
+func (q *RateLimitedQueue[T]) Put(ctx context.Context, v T) error {
+	return q.backlog.Put(ctx, v)
+}
+
+// Take waits for an element, then for a token. If ctx is done before the token is available
+// the element is kept and returned by the next Take, so neither the element nor the token is lost
+func (q *RateLimitedQueue[T]) Take(ctx context.Context) (T, error) {
+	var zero T
+	if err := ctx.Err(); err != nil {
+		return zero, err
+	}
+
+	q.mutex.Lock()
+	v, err := q.pending.PopFront()
+	q.mutex.Unlock()
+	if err != nil {
+		if v, err = q.backlog.Take(ctx); err != nil {
+			return zero, err
+		}
+	}
+	if err = q.limiter.Wait(ctx); err != nil {
+		q.mutex.Lock()
+		q.pending.PushFront(v)
+		q.mutex.Unlock()
+		return zero, err
+	}
+	return v, nil
+}
+
+// Len returns the number of elements in the backlog plus the ones kept after a failed Take
+func (q *RateLimitedQueue[T]) Len() int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return q.backlog.Len() + q.pending.Len()
+}
+
+func (q *RateLimitedQueue[T]) Close() {
+	q.backlog.Close()
+}
+
+type KeyedLimiterOptions[K comparable] struct {
+	New             func(K) Limiter // 为新的 key 创建限流器
+	IdleTimeout     time.Duration   // key 空闲多久后删除其限流器，0 表示不删除
+	CleanupInterval time.Duration   // 后台清理空闲 key 的间隔，0 表示只在访问时清理
+	Clock           Clock           // 默认为 SystemClock
+}
+
+type keyedLimiter struct {
+	limiter  Limiter
+	lastUsed time.Time
+}
+
+// KeyedLimiter 按 key 独立限流，例如每个用户或每个 IP 一个限流器
+type KeyedLimiter[K comparable] struct {
+	mutex    sync.Mutex
+	opts     KeyedLimiterOptions[K]
+	limiters map[K]*keyedLimiter
+	swept    time.Time
+	stop     chan struct{}
+}
+
+func NewKeyedLimiter[K comparable](opts KeyedLimiterOptions[K]) *KeyedLimiter[K] {
+	if opts.Clock == nil {
+		opts.Clock = SystemClock
+	}
+	l := &KeyedLimiter[K]{
+		opts:     opts,
+		limiters: make(map[K]*keyedLimiter),
+		swept:    opts.Clock.Now(),
+	}
+	if opts.IdleTimeout > 0 && opts.CleanupInterval > 0 {
+		l.stop = make(chan struct{})
+		go l.janitor(opts.CleanupInterval, l.stop)
+	}
+	return l
+}
+
+func (l *KeyedLimiter[K]) janitor(interval time.Duration, stop chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			l.EvictIdle()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// get 返回 key 的限流器并刷新其使用时间
+func (l *KeyedLimiter[K]) get(key K) Limiter {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	now := l.opts.Clock.Now()
+	// 没有后台清理时，每隔 IdleTimeout 在访问时清理一次
+	if l.opts.IdleTimeout > 0 && now.Sub(l.swept) >= l.opts.IdleTimeout {
+		l.evictIdle(now)
+	}
+	e, ok := l.limiters[key]
+	if !ok {
+		e = &keyedLimiter{limiter: l.opts.New(key)}
+		l.limiters[key] = e
+	}
+	e.lastUsed = now
+	return e.limiter
+}
+
+func (l *KeyedLimiter[K]) Allow(key K) bool {
+	return l.get(key).Allow()
+}
+
+func (l *KeyedLimiter[K]) Wait(ctx context.Context, key K) error {
+	return l.get(key).Wait(ctx)
+}
+
+// Len returns the number of keys with a limiter
+func (l *KeyedLimiter[K]) Len() int {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return len(l.limiters)
+}
+
+// EvictIdle removes the limiters unused for IdleTimeout and returns how many were removed
+func (l *KeyedLimiter[K]) EvictIdle() int {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.opts.IdleTimeout <= 0 {
+		return 0
+	}
+	return l.evictIdle(l.opts.Clock.Now())
+}
+
+// evictIdle 调用方需持有锁
+func (l *KeyedLimiter[K]) evictIdle(now time.Time) int {
+	n := 0
+	for key, e := range l.limiters {
+		if now.Sub(e.lastUsed) >= l.opts.IdleTimeout {
+			delete(l.limiters, key)
+			n++
+		}
+	}
+	l.swept = now
+	return n
+}
+
+// Close stops the background cleanup
+func (l *KeyedLimiter[K]) Close() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.stop != nil {
+		close(l.stop)
+		l.stop = nil
+	}
+}
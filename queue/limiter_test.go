@@ -0,0 +1,197 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTokenBucketAllow(t *testing.T) {
+	clock := newFakeClock()
+	b := NewTokenBucketWithClock(10, 2, clock)
+	if !b.Allow() || !b.Allow() {
+		t.Fatal("a full bucket should allow a burst")
+	}
+	if b.Allow() {
+		t.Fatal("an empty bucket should not allow")
+	}
+	clock.Advance(100 * time.Millisecond)
+	if !b.Allow() || b.Allow() {
+		t.Fatal("one token should be refilled after 100ms")
+	}
+	clock.Advance(time.Hour)
+	if tokens := b.Tokens(); tokens != 2 {
+		t.Fatalf("Tokens: got %v, want the burst of 2", tokens)
+	}
+}
+
+func TestTokenBucketWait(t *testing.T) {
+	clock := newFakeClock()
+	b := NewTokenBucketWithClock(10, 1, clock)
+	_ = b.Wait(context.Background())
+
+	done := make(chan error, 1)
+	go func() {
+		done <- b.Wait(context.Background())
+	}()
+	clock.waitTimerAt(t, clock.Now().Add(100*time.Millisecond))
+	select {
+	case err := <-done:
+		t.Fatalf("Wait returned %v before a token was available", err)
+	default:
+	}
+	clock.Advance(100 * time.Millisecond)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	// 截止时间早于下一个令牌时立即失败
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := b.Wait(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("Wait: got %v, want DeadlineExceeded", err)
+	}
+}
+
+func TestLeakyBucket(t *testing.T) {
+	clock := newFakeClock()
+	b := NewLeakyBucketWithClock(10, 1, clock)
+	if !b.Allow() || b.Allow() {
+		t.Fatal("the leaky bucket should let one call through per interval")
+	}
+	clock.Advance(100 * time.Millisecond)
+
+	// 第一个调用立即放行，第二个排队等待，第三个超出容量
+	if err := b.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- b.Wait(context.Background())
+	}()
+	clock.waitTimers(t, 1)
+	if err := b.Wait(context.Background()); err != ErrFull {
+		t.Fatalf("Wait beyond capacity: got %v, want ErrFull", err)
+	}
+	clock.Advance(100 * time.Millisecond)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLeakyBucketZeroRate(t *testing.T) {
+	for _, rate := range []float64{0, -1, 1e-12} {
+		b := NewLeakyBucketWithClock(rate, 2, newFakeClock())
+		if rate <= 0 && b.Allow() {
+			t.Fatalf("rate %v: Allow should never succeed", rate)
+		}
+		if rate > 0 && !b.Allow() {
+			t.Fatalf("rate %v: the first call should be allowed", rate)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		if err := b.Wait(ctx); err != context.DeadlineExceeded {
+			t.Fatalf("rate %v: Wait got %v, want DeadlineExceeded", rate, err)
+		}
+		cancel()
+	}
+}
+
+func TestRateLimitedQueue(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+	q := NewRateLimitedQueue[int](NewBlockingQueue[int](0), NewTokenBucketWithClock(10, 1, clock))
+	_ = q.Put(ctx, 1)
+	_ = q.Put(ctx, 2)
+	if v, err := q.Take(ctx); err != nil || v != 1 {
+		t.Fatalf("Take: got %d %v, want 1", v, err)
+	}
+
+	result := make(chan int, 1)
+	go func() {
+		v, _ := q.Take(ctx)
+		result <- v
+	}()
+	clock.waitTimers(t, 1)
+	clock.Advance(100 * time.Millisecond)
+	if v := <-result; v != 2 {
+		t.Fatalf("Take: got %d, want 2", v)
+	}
+}
+
+func TestRateLimitedQueueKeepsTokenAndElement(t *testing.T) {
+	clock := newFakeClock()
+	bucket := NewTokenBucketWithClock(10, 1, clock)
+	backlog := NewBlockingQueue[int](0)
+	q := NewRateLimitedQueue[int](backlog, bucket)
+
+	// 没有元素时不消耗令牌
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := q.Take(ctx); err != context.Canceled {
+		t.Fatalf("Take with a canceled ctx: got %v, want context.Canceled", err)
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := q.Take(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("Take on an empty queue: got %v, want context.DeadlineExceeded", err)
+	}
+	if tokens := bucket.Tokens(); tokens != 1 {
+		t.Fatalf("Tokens after a failed Take: got %v, want 1", tokens)
+	}
+
+	ctx = context.Background()
+	_ = q.Put(ctx, 1)
+	_ = q.Put(ctx, 2)
+	if v, err := q.Take(ctx); err != nil || v != 1 {
+		t.Fatalf("Take: got %d %v, want 1", v, err)
+	}
+	// 等待令牌时 ctx 结束，元素保留给下一次 Take
+	ctx, cancel = context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	go func() {
+		_, err := q.Take(ctx)
+		result <- err
+	}()
+	clock.waitTimers(t, 1)
+	cancel()
+	if err := <-result; err != context.Canceled {
+		t.Fatalf("Take: got %v, want context.Canceled", err)
+	}
+	if q.Len() != 1 {
+		t.Fatalf("Len after a canceled Take: got %d, want 1", q.Len())
+	}
+
+	q.Close()
+	clock.Advance(100 * time.Millisecond)
+	if v, err := q.Take(context.Background()); err != nil || v != 2 {
+		t.Fatalf("Take after Close: got %d %v, want 2", v, err)
+	}
+	if _, err := q.Take(context.Background()); err != ErrClosed {
+		t.Fatalf("Take on a drained queue: got %v, want ErrClosed", err)
+	}
+}
+
+func TestKeyedLimiter(t *testing.T) {
+	clock := newFakeClock()
+	l := NewKeyedLimiter(KeyedLimiterOptions[string]{
+		New: func(string) Limiter {
+			return NewTokenBucketWithClock(1, 1, clock)
+		},
+		IdleTimeout: time.Minute,
+		Clock:       clock,
+	})
+	defer l.Close()
+
+	if !l.Allow("a") || l.Allow("a") {
+		t.Fatal("each key should have its own bucket of 1")
+	}
+	if !l.Allow("b") {
+		t.Fatal("key b should not be limited by key a")
+	}
+	clock.Advance(30 * time.Second)
+	l.Allow("b")
+	clock.Advance(30 * time.Second)
+	if n := l.EvictIdle(); n != 1 || l.Len() != 1 {
+		t.Fatalf("EvictIdle: removed %d, %d left, want only a removed", n, l.Len())
+	}
+}
@@ -1,57 +1,242 @@
 package queue
 
-import (
-	"errors"
-)
-
 // 链式列表
 
-type doublyLinkedNode struct {
+// LinkedNode 双向链表的节点
+type LinkedNode[T any] struct {
+	Value T
+
+	prev, next *LinkedNode[T]
+	list       *LinkedList[T]
 }
 
-type doublyLinkedList struct {
-	member []*doublyLinkedNode
-	// length & cap
-	cap int
+// Next returns the next node or nil
+func (n *LinkedNode[T]) Next() *LinkedNode[T] {
+	if n.list != nil && n.next != &n.list.root {
+		return n.next
+	}
+	return nil
 }
 
-func NewLink(size int) *link {
-	return &link{
-		member: make([]any, size),
-		cap:    size,
-		front:  0,
-		rear:   0,
+// Prev returns the previous node or nil
+func (n *LinkedNode[T]) Prev() *LinkedNode[T] {
+	if n.list != nil && n.prev != &n.list.root {
+		return n.prev
 	}
+	return nil
+}
+
+// LinkedList 双向链表，零值即可使用
+// root 为哨兵节点，root.next 指向头节点，root.prev 指向尾节点
+type LinkedList[T any] struct {
+	root LinkedNode[T]
+	len  int
 }
 
-func (q *LoopQueue) IsEmpty() bool {
-	return q.front == q.rear
+func NewLinkedList[T any]() *LinkedList[T] {
+	return new(LinkedList[T]).init()
 }
 
-func (q *LoopQueue) IsFull() bool {
-	return (q.rear+1)%q.cap == q.front
+func (l *LinkedList[T]) init() *LinkedList[T] {
+	l.root.next = &l.root
+	l.root.prev = &l.root
+	l.len = 0
+	return l
 }
 
-func (q *LoopQueue) Push(val any) error {
-	if q.IsFull() {
-		return errors.New("queue is full")
+// lazyInit 零值的链表在第一次写入时初始化
+func (l *LinkedList[T]) lazyInit() {
+	if l.root.next == nil {
+		l.init()
 	}
+}
 
-	q.member[q.rear] = val
-	// 当队尾达到最大index就不能简单自增而是要循环
-	q.rear = (q.rear + 1) % q.cap
+// Len returns the number of nodes in the list
+func (l *LinkedList[T]) Len() int {
+	return l.len
+}
 
-	return nil
+func (l *LinkedList[T]) IsEmpty() bool {
+	return l.len == 0
+}
+
+// Front returns the first node or nil
+func (l *LinkedList[T]) Front() *LinkedNode[T] {
+	if l.len == 0 {
+		return nil
+	}
+	return l.root.next
+}
+
+// Back returns the last node or nil
+func (l *LinkedList[T]) Back() *LinkedNode[T] {
+	if l.len == 0 {
+		return nil
+	}
+	return l.root.prev
+}
+
+// insert 将 n 插入到 at 之后
+func (l *LinkedList[T]) insert(n, at *LinkedNode[T]) *LinkedNode[T] {
+	n.prev = at
+	n.next = at.next
+	n.prev.next = n
+	n.next.prev = n
+	n.list = l
+	l.len++
+	return n
+}
+
+// unlink 将 n 从链表中摘除
+func (l *LinkedList[T]) unlink(n *LinkedNode[T]) {
+	n.prev.next = n.next
+	n.next.prev = n.prev
+	n.next = nil
+	n.prev = nil
+	n.list = nil
+	l.len--
+}
+
+// move 将 n 移动到 at 之后
+func (l *LinkedList[T]) move(n, at *LinkedNode[T]) {
+	if n == at {
+		return
+	}
+	n.prev.next = n.next
+	n.next.prev = n.prev
+
+	n.prev = at
+	n.next = at.next
+	n.prev.next = n
+	n.next.prev = n
+}
+
+// PushFront inserts v at the front of the list and returns its node
+func (l *LinkedList[T]) PushFront(v T) *LinkedNode[T] {
+	l.lazyInit()
+	return l.insert(&LinkedNode[T]{Value: v}, &l.root)
+}
+
+// PushBack inserts v at the back of the list and returns its node
+func (l *LinkedList[T]) PushBack(v T) *LinkedNode[T] {
+	l.lazyInit()
+	return l.insert(&LinkedNode[T]{Value: v}, l.root.prev)
+}
+
+// PopFront removes the first node and returns its value
+func (l *LinkedList[T]) PopFront() (T, error) {
+	if l.len == 0 {
+		var zero T
+		return zero, ErrEmpty
+	}
+	return l.Remove(l.root.next), nil
 }
 
-func (q *LoopQueue) Pop() (any, error) {
-	if q.IsEmpty() {
-		return nil, errors.New("empty queue")
+// PopBack removes the last node and returns its value
+func (l *LinkedList[T]) PopBack() (T, error) {
+	if l.len == 0 {
+		var zero T
+		return zero, ErrEmpty
 	}
+	return l.Remove(l.root.prev), nil
+}
+
+// InsertBefore inserts v immediately before mark and returns its node,
+// mark must belong to the list, otherwise nil is returned
+func (l *LinkedList[T]) InsertBefore(v T, mark *LinkedNode[T]) *LinkedNode[T] {
+	if mark.list != l {
+		return nil
+	}
+	return l.insert(&LinkedNode[T]{Value: v}, mark.prev)
+}
+
+// InsertAfter inserts v immediately after mark and returns its node,
+// mark must belong to the list, otherwise nil is returned
+func (l *LinkedList[T]) InsertAfter(v T, mark *LinkedNode[T]) *LinkedNode[T] {
+	if mark.list != l {
+		return nil
+	}
+	return l.insert(&LinkedNode[T]{Value: v}, mark)
+}
+
+// Remove removes n from the list in O(1) and returns its value,
+// nothing happens if n does not belong to the list
+func (l *LinkedList[T]) Remove(n *LinkedNode[T]) T {
+	if n.list == l {
+		l.unlink(n)
+	}
+	return n.Value
+}
+
+// MoveToFront moves n to the front of the list
+func (l *LinkedList[T]) MoveToFront(n *LinkedNode[T]) {
+	if n.list != l || l.root.next == n {
+		return
+	}
+	l.move(n, &l.root)
+}
 
-	pop := q.member[q.front]
-	// 当队头达到最大index就不能简单自增而是要循环
-	q.front = (q.front + 1) % q.cap
+// MoveToBack moves n to the back of the list
+func (l *LinkedList[T]) MoveToBack(n *LinkedNode[T]) {
+	if n.list != l || l.root.prev == n {
+		return
+	}
+	l.move(n, l.root.prev)
+}
 
-	return pop, nil
+// MoveBefore moves n immediately before mark
+func (l *LinkedList[T]) MoveBefore(n, mark *LinkedNode[T]) {
+	if n.list != l || mark.list != l || n == mark {
+		return
+	}
+	l.move(n, mark.prev)
+}
+
+// MoveAfter moves n immediately after mark
+func (l *LinkedList[T]) MoveAfter(n, mark *LinkedNode[T]) {
+	if n.list != l || mark.list != l || n == mark {
+		return
+	}
+	l.move(n, mark)
+}
+
+// Range calls fn for each value from front to back until fn returns false
+func (l *LinkedList[T]) Range(fn func(v T) bool) {
+	for n := l.Front(); n != nil; {
+		next := n.Next()
+		if !fn(n.Value) {
+			return
+		}
+		n = next
+	}
+}
+
+// RangeReverse calls fn for each value from back to front until fn returns false
+func (l *LinkedList[T]) RangeReverse(fn func(v T) bool) {
+	for n := l.Back(); n != nil; {
+		prev := n.Prev()
+		if !fn(n.Value) {
+			return
+		}
+		n = prev
+	}
+}
+
+// Values returns the values of the list from front to back
+func (l *LinkedList[T]) Values() []T {
+	values := make([]T, 0, l.len)
+	for n := l.Front(); n != nil; n = n.Next() {
+		values = append(values, n.Value)
+	}
+	return values
+}
+
+// Clear removes all nodes from the list
+func (l *LinkedList[T]) Clear() {
+	for n := l.Front(); n != nil; {
+		next := n.Next()
+		n.prev, n.next, n.list = nil, nil, nil
+		n = next
+	}
+	l.init()
 }
@@ -0,0 +1,155 @@
+package queue
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// checkLinkedList 检查链表从前往后和从后往前遍历的结果都与 want 一致
+func checkLinkedList(t *testing.T, l *LinkedList[int], want []*LinkedNode[int]) {
+	t.Helper()
+	if l.Len() != len(want) {
+		t.Fatalf("Len: got %d, want %d", l.Len(), len(want))
+	}
+	i := 0
+	for n := l.Front(); n != nil; n = n.Next() {
+		if i >= len(want) || n != want[i] {
+			t.Fatalf("forward traversal differs at %d", i)
+		}
+		i++
+	}
+	if i != len(want) {
+		t.Fatalf("forward traversal visited %d nodes, want %d", i, len(want))
+	}
+	for n := l.Back(); n != nil; n = n.Prev() {
+		i--
+		if n != want[i] {
+			t.Fatalf("backward traversal differs at %d", i)
+		}
+	}
+}
+
+func TestLinkedListRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	var l LinkedList[int]
+	var want []*LinkedNode[int]
+	other := NewLinkedList[int]()
+	foreign := other.PushBack(-1)
+
+	insertAt := func(i int, n *LinkedNode[int]) {
+		want = append(want[:i], append([]*LinkedNode[int]{n}, want[i:]...)...)
+	}
+	removeAt := func(i int) *LinkedNode[int] {
+		n := want[i]
+		want = append(want[:i], want[i+1:]...)
+		return n
+	}
+	indexOf := func(n *LinkedNode[int]) int {
+		for i := range want {
+			if want[i] == n {
+				return i
+			}
+		}
+		return -1
+	}
+	for i := 0; i < 3000; i++ {
+		if len(want) == 0 {
+			want = append(want, l.PushBack(i))
+			continue
+		}
+		j, k := r.Intn(len(want)), r.Intn(len(want))
+		switch r.Intn(10) {
+		case 0:
+			insertAt(0, l.PushFront(i))
+		case 1:
+			want = append(want, l.PushBack(i))
+		case 2:
+			insertAt(j, l.InsertBefore(i, want[j]))
+		case 3:
+			insertAt(j+1, l.InsertAfter(i, want[j]))
+		case 4:
+			n := removeAt(j)
+			if v := l.Remove(n); v != n.Value {
+				t.Fatalf("Remove: got %d, want %d", v, n.Value)
+			}
+			// 已删除的节点不再属于链表
+			if n.Next() != nil || n.Prev() != nil || l.InsertAfter(0, n) != nil {
+				t.Fatal("a removed node is still linked")
+			}
+		case 5:
+			l.MoveToFront(want[j])
+			insertAt(0, removeAt(j))
+		case 6:
+			l.MoveToBack(want[j])
+			want = append(want, removeAt(j))
+		case 7:
+			n, mark := want[j], want[k]
+			l.MoveBefore(n, mark)
+			if n != mark {
+				removeAt(j)
+				insertAt(indexOf(mark), n)
+			}
+		case 8:
+			n, mark := want[j], want[k]
+			l.MoveAfter(n, mark)
+			if n != mark {
+				removeAt(j)
+				insertAt(indexOf(mark)+1, n)
+			}
+		case 9:
+			// 不属于该链表的节点不会被修改
+			l.Remove(foreign)
+			l.MoveToFront(foreign)
+			l.MoveAfter(foreign, want[j])
+			if l.InsertBefore(0, foreign) != nil || other.Len() != 1 {
+				t.Fatal("an operation with a foreign node modified a list")
+			}
+		}
+		checkLinkedList(t, &l, want)
+	}
+
+	values := l.Values()
+	for i, n := range want {
+		if values[i] != n.Value {
+			t.Fatalf("Values[%d]: got %d, want %d", i, values[i], n.Value)
+		}
+	}
+	l.Clear()
+	checkLinkedList(t, &l, nil)
+	if want[0].Next() != nil {
+		t.Fatal("a node is still linked after Clear")
+	}
+}
+
+func TestLinkedListRangeRemove(t *testing.T) {
+	l := NewLinkedList[int]()
+	nodes := make(map[int]*LinkedNode[int])
+	for i := 0; i < 5; i++ {
+		nodes[i] = l.PushBack(i)
+	}
+	// 遍历时可以删除当前节点
+	var got []int
+	l.Range(func(v int) bool {
+		got = append(got, v)
+		l.Remove(nodes[v])
+		return v < 2
+	})
+	expectInts(t, got, 0, 1, 2)
+	expectInts(t, l.Values(), 3, 4)
+
+	got = nil
+	l.RangeReverse(func(v int) bool {
+		got = append(got, v)
+		return true
+	})
+	expectInts(t, got, 4, 3)
+	if v, err := l.PopFront(); v != 3 || err != nil {
+		t.Fatalf("PopFront: got %d %v, want 3", v, err)
+	}
+	if v, err := l.PopBack(); v != 4 || err != nil {
+		t.Fatalf("PopBack: got %d %v, want 4", v, err)
+	}
+	if _, err := l.PopBack(); err != ErrEmpty {
+		t.Fatalf("PopBack on an empty list: got %v, want ErrEmpty", err)
+	}
+}
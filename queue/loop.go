@@ -1,55 +1,307 @@
 package queue
 
-import "errors"
+import (
+	"sync"
+	"time"
+)
 
 // 环状队列
 
-type LoopQueue struct {
-	member []any
+const minGrowCap = 4
+
+type LoopQueue[T any] struct {
+	member []T
 
 	// length & cap
-	cap int
+	len, cap int
 	// index
 	front, rear int
+	// 队列满时是否自动扩容
+	grow bool
+
+	hook     Hook[T]
+	enqueued *LoopQueue[time.Time] // 设置 hook 时记录每个元素的入队时间
 }
 
-func NewLoopQueue(size int) *LoopQueue {
-	return &LoopQueue{
-		member: make([]any, size),
+// NewLoopQueue returns a fixed size queue holding at most size elements
+func NewLoopQueue[T any](size int) *LoopQueue[T] {
+	if size < 0 {
+		size = 0
+	}
+	return &LoopQueue[T]{
+		member: make([]T, size),
 		cap:    size,
-		front:  0,
-		rear:   0,
 	}
 }
 
-func (q *LoopQueue) IsEmpty() bool {
-	return q.front == q.rear
+// NewGrowableLoopQueue returns a queue with an initial capacity of size that doubles when full
+func NewGrowableLoopQueue[T any](size int) *LoopQueue[T] {
+	q := NewLoopQueue[T](size)
+	q.grow = true
+	return q
+}
+
+// SetHook reports the events of the queue to hook, a nil hook removes it.
+// Elements already in the queue are considered enqueued now
+func (q *LoopQueue[T]) SetHook(hook Hook[T]) {
+	q.hook = hook
+	q.enqueued = nil
+	if hook != nil {
+		q.enqueued = newEnqueueTimes(q.len)
+	}
+}
+
+func (q *LoopQueue[T]) IsEmpty() bool {
+	return q.len == 0
+}
+
+func (q *LoopQueue[T]) IsFull() bool {
+	return !q.grow && q.len == q.cap
+}
+
+// Len returns the number of elements in the queue
+func (q *LoopQueue[T]) Len() int {
+	return q.len
 }
 
-func (q *LoopQueue) IsFull() bool {
-	return (q.rear+1)%q.cap == q.front
+// Cap returns the current capacity of the queue
+func (q *LoopQueue[T]) Cap() int {
+	return q.cap
 }
 
-func (q *LoopQueue) Push(val any) error {
-	if q.IsFull() {
-		return errors.New("queue is full")
+func (q *LoopQueue[T]) Push(val T) error {
+	if q.len == q.cap {
+		if !q.grow {
+			if q.hook != nil {
+				q.hook.OnDrop(val, ErrFull)
+			}
+			return ErrFull
+		}
+		q.resize()
 	}
 
 	q.member[q.rear] = val
 	// 当队尾达到最大index就不能简单自增而是要循环
 	q.rear = (q.rear + 1) % q.cap
+	q.len++
 
+	if q.hook != nil {
+		pushEnqueueTime(q.enqueued)
+		q.hook.OnEnqueue(val, q.len)
+	}
 	return nil
 }
 
-func (q *LoopQueue) Pop() (any, error) {
+func (q *LoopQueue[T]) Pop() (T, error) {
+	var zero T
 	if q.IsEmpty() {
-		return nil, errors.New("empty queue")
+		return zero, ErrEmpty
 	}
 
 	pop := q.member[q.front]
+	// 清空已出队的位置，避免引用的对象无法被回收
+	q.member[q.front] = zero
 	// 当队头达到最大index就不能简单自增而是要循环
 	q.front = (q.front + 1) % q.cap
+	q.len--
 
+	if q.hook != nil {
+		q.hook.OnDequeue(pop, popWait(q.enqueued), q.len)
+	}
 	return pop, nil
 }
+
+// Peek returns the element at the front of the queue without removing it
+func (q *LoopQueue[T]) Peek() (T, error) {
+	if q.IsEmpty() {
+		var zero T
+		return zero, ErrEmpty
+	}
+	return q.member[q.front], nil
+}
+
+// PeekBack returns the element at the back of the queue without removing it
+func (q *LoopQueue[T]) PeekBack() (T, error) {
+	if q.IsEmpty() {
+		var zero T
+		return zero, ErrEmpty
+	}
+	return q.member[(q.rear-1+q.cap)%q.cap], nil
+}
+
+// Clear removes all elements from the queue, the hook is not notified
+func (q *LoopQueue[T]) Clear() {
+	var zero T
+	for i := range q.member {
+		q.member[i] = zero
+	}
+	q.len, q.front, q.rear = 0, 0, 0
+	resetEnqueueTimes(q.enqueued, 0)
+}
+
+// Drain removes all elements from the queue and returns them in FIFO order,
+// the hook sees one dequeue per element
+func (q *LoopQueue[T]) Drain() []T {
+	items := q.items()
+	if q.hook != nil {
+		for i, v := range items {
+			q.hook.OnDequeue(v, popWait(q.enqueued), len(items)-i-1)
+		}
+	}
+	q.Clear()
+	return items
+}
+
+// items 按出队顺序复制队列中的元素
+func (q *LoopQueue[T]) items() []T {
+	items := make([]T, q.len)
+	if q.len == 0 {
+		return items
+	}
+	if q.front < q.rear {
+		copy(items, q.member[q.front:q.rear])
+	} else {
+		n := copy(items, q.member[q.front:])
+		copy(items[n:], q.member[:q.rear])
+	}
+	return items
+}
+
+// resize 容量翻倍，并将元素重新排列到新数组的开头
+func (q *LoopQueue[T]) resize() {
+	newCap := q.cap * 2
+	if newCap < minGrowCap {
+		newCap = minGrowCap
+	}
+	member := make([]T, newCap)
+	n := copy(member, q.items())
+	q.member, q.cap = member, newCap
+	q.front, q.rear = 0, n%newCap
+}
+
+// SyncLoopQueue 并发安全的环状队列
+type SyncLoopQueue[T any] struct {
+	mutex sync.Mutex
+	queue *LoopQueue[T]
+
+	// 钩子在解锁后调用，所以不使用内部 LoopQueue 的钩子
+	hook     Hook[T]
+	enqueued *LoopQueue[time.Time]
+}
+
+// NewSyncLoopQueue wraps q so that it can be used from multiple goroutines,
+// q must not be used directly afterwards
+func NewSyncLoopQueue[T any](q *LoopQueue[T]) *SyncLoopQueue[T] {
+	return &SyncLoopQueue[T]{queue: q}
+}
+
+// SetHook reports the events of the queue to hook, a nil hook removes it.
+// Elements already in the queue are considered enqueued now
+func (q *SyncLoopQueue[T]) SetHook(hook Hook[T]) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.hook = hook
+	q.enqueued = nil
+	if hook != nil {
+		q.enqueued = newEnqueueTimes(q.queue.Len())
+	}
+}
+
+func (q *SyncLoopQueue[T]) IsEmpty() bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return q.queue.IsEmpty()
+}
+
+func (q *SyncLoopQueue[T]) IsFull() bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return q.queue.IsFull()
+}
+
+func (q *SyncLoopQueue[T]) Len() int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return q.queue.Len()
+}
+
+func (q *SyncLoopQueue[T]) Cap() int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return q.queue.Cap()
+}
+
+func (q *SyncLoopQueue[T]) Push(val T) error {
+	q.mutex.Lock()
+	err := q.queue.Push(val)
+	hook, depth := q.hook, q.queue.Len()
+	if err == nil {
+		pushEnqueueTime(q.enqueued)
+	}
+	q.mutex.Unlock()
+
+	if hook != nil {
+		if err != nil {
+			hook.OnDrop(val, err)
+		} else {
+			hook.OnEnqueue(val, depth)
+		}
+	}
+	return err
+}
+
+func (q *SyncLoopQueue[T]) Pop() (T, error) {
+	q.mutex.Lock()
+	val, err := q.queue.Pop()
+	hook, depth := q.hook, q.queue.Len()
+	var wait time.Duration
+	if err == nil {
+		wait = popWait(q.enqueued)
+	}
+	q.mutex.Unlock()
+
+	if hook != nil && err == nil {
+		hook.OnDequeue(val, wait, depth)
+	}
+	return val, err
+}
+
+func (q *SyncLoopQueue[T]) Peek() (T, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return q.queue.Peek()
+}
+
+func (q *SyncLoopQueue[T]) PeekBack() (T, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return q.queue.PeekBack()
+}
+
+func (q *SyncLoopQueue[T]) Clear() {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.queue.Clear()
+	resetEnqueueTimes(q.enqueued, 0)
+}
+
+func (q *SyncLoopQueue[T]) Drain() []T {
+	q.mutex.Lock()
+	items := q.queue.Drain()
+	hook := q.hook
+	var waits []time.Duration
+	if hook != nil {
+		waits = make([]time.Duration, len(items))
+		for i := range items {
+			waits[i] = popWait(q.enqueued)
+		}
+	}
+	q.mutex.Unlock()
+
+	if hook != nil {
+		for i, v := range items {
+			hook.OnDequeue(v, waits[i], len(items)-i-1)
+		}
+	}
+	return items
+}
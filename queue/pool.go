@@ -0,0 +1,358 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 工作池，从 Backlog 中取出任务交给工作协程执行
+
+// Backlog 可以作为工作池任务队列的阻塞队列，其他队列通过 backlog.go 中的适配器实现
+type Backlog[T any] interface {
+	Put(ctx context.Context, v T) error
+	Take(ctx context.Context) (T, error)
+	Len() int
+	Close()
+}
+
+var (
+	_ Backlog[int] = (*BlockingQueue[int])(nil)
+	_ Backlog[int] = (*BlockingPriorityQueue[int])(nil)
+)
+
+// ErrPoolClosed 工作池已关闭
+var ErrPoolClosed = errors.New("queue: pool is closed")
+
+// Job 工作池中等待执行的任务
+type Job struct {
+	Priority int // 使用 JobLess 排序的优先级队列作为 Backlog 时，数值大的任务先执行
+
+	seq       uint64
+	submitted time.Time
+	run       func(ctx context.Context) error
+	fail      func(err error)
+}
+
+// JobLess orders jobs by descending Priority then by submission order,
+// to be used with NewBlockingPriorityQueue as the pool backlog
+func JobLess(a, b *Job) bool {
+	if a.Priority == b.Priority {
+		return a.seq < b.seq
+	}
+	return a.Priority > b.Priority
+}
+
+// Future 任务的执行结果
+type Future[T any] struct {
+	done  chan struct{}
+	once  sync.Once
+	value T
+	err   error
+}
+
+// Done returns a channel closed once the task has finished
+func (f *Future[T]) Done() <-chan struct{} {
+	return f.done
+}
+
+// Wait waits for the task to finish and returns its result
+func (f *Future[T]) Wait(ctx context.Context) (T, error) {
+	select {
+	case <-f.done:
+		return f.value, f.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// finish 只有第一次调用生效，任务超时后的结果会被忽略
+func (f *Future[T]) finish(v T, err error) {
+	f.once.Do(func() {
+		f.value, f.err = v, err
+		close(f.done)
+	})
+}
+
+type PoolOptions struct {
+	MinWorkers  int           // 常驻的工作协程数，默认 1
+	MaxWorkers  int           // 最大工作协程数，大于 MinWorkers 时按积压情况自动扩容，默认等于 MinWorkers
+	IdleTimeout time.Duration // 超出 MinWorkers 的工作协程空闲多久后退出，默认 1 分钟
+	TaskTimeout time.Duration // 单个任务的超时时间，0 表示不限制，超时后 Future 立即返回 context.DeadlineExceeded
+	QueueSize   int           // 默认 Backlog 的容量，0 表示不限制
+	Backlog     Backlog[*Job] // 任务队列，默认为容量 QueueSize 的 BlockingQueue
+}
+
+// PoolStats 工作池的统计信息
+type PoolStats struct {
+	Workers   int           // 当前工作协程数
+	Busy      int           // 正在执行任务的工作协程数
+	Queued    int           // 积压的任务数
+	Submitted uint64        // 提交的任务数
+	Completed uint64        // 执行成功的任务数
+	Failed    uint64        // 返回错误、panic 或超时的任务数
+	AvgWait   time.Duration // 已执行的任务在队列中的平均等待时间
+	AvgRun    time.Duration // 已执行的任务的平均执行时间
+}
+
+type Pool struct {
+	opts    PoolOptions
+	backlog Backlog[*Job]
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+
+	mutex   sync.Mutex
+	workers int
+	busy    int
+	closed  bool
+
+	seq       atomic.Uint64
+	submitted atomic.Uint64
+	completed atomic.Uint64
+	failed    atomic.Uint64
+	executed  atomic.Uint64 // 实际执行过的任务数，不包括关闭时未执行就失败的任务
+	waitTotal atomic.Int64
+	runTotal  atomic.Int64
+}
+
+func NewPool(opts PoolOptions) *Pool {
+	if opts.MinWorkers <= 0 {
+		opts.MinWorkers = 1
+	}
+	if opts.MaxWorkers < opts.MinWorkers {
+		opts.MaxWorkers = opts.MinWorkers
+	}
+	if opts.IdleTimeout <= 0 {
+		opts.IdleTimeout = time.Minute
+	}
+	if opts.Backlog == nil {
+		opts.Backlog = NewBlockingQueue[*Job](opts.QueueSize)
+	}
+
+	p := &Pool{opts: opts, backlog: opts.Backlog}
+	p.ctx, p.cancel = context.WithCancel(context.Background())
+	p.mutex.Lock()
+	for i := 0; i < opts.MinWorkers; i++ {
+		p.spawn()
+	}
+	p.mutex.Unlock()
+	return p
+}
+
+// Submit runs fn in the pool, waiting for space in the backlog if it is full
+func (p *Pool) Submit(ctx context.Context, fn func(ctx context.Context) error) (*Future[struct{}], error) {
+	return SubmitFunc(ctx, p, 0, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, fn(ctx)
+	})
+}
+
+// SubmitFunc runs fn in the pool and returns a future holding its result,
+// priority only matters when the backlog is a priority queue ordered by JobLess
+func SubmitFunc[T any](ctx context.Context, p *Pool, priority int, fn func(ctx context.Context) (T, error)) (*Future[T], error) {
+	f := &Future[T]{done: make(chan struct{})}
+	job := &Job{
+		Priority:  priority,
+		seq:       p.seq.Add(1),
+		submitted: time.Now(),
+		run: func(ctx context.Context) error {
+			v, err := callTask(ctx, fn)
+			f.finish(v, err)
+			return err
+		},
+		fail: func(err error) {
+			var zero T
+			f.finish(zero, err)
+		},
+	}
+
+	p.mutex.Lock()
+	closed := p.closed
+	p.mutex.Unlock()
+	if closed {
+		return nil, ErrPoolClosed
+	}
+	if err := p.backlog.Put(ctx, job); err != nil {
+		if err == ErrClosed {
+			err = ErrPoolClosed
+		}
+		return nil, err
+	}
+	p.submitted.Add(1)
+	p.scale()
+	return f, nil
+}
+
+// callTask 调用 fn 并把 panic 转换为错误
+func callTask[T any](ctx context.Context, fn func(ctx context.Context) (T, error)) (v T, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("queue: task panic: %v", r)
+		}
+	}()
+	return fn(ctx)
+}
+
+// scale 有积压且没有空闲的工作协程时扩容
+func (p *Pool) scale() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.scaleLocked()
+}
+
+// scaleLocked 调用方需持有锁
+func (p *Pool) scaleLocked() {
+	if !p.closed && p.workers < p.opts.MaxWorkers && p.busy >= p.workers && p.backlog.Len() > 0 {
+		p.spawn()
+	}
+}
+
+// spawn 调用方需持有锁
+func (p *Pool) spawn() {
+	p.workers++
+	p.wg.Add(1)
+	go p.worker()
+}
+
+func (p *Pool) worker() {
+	defer p.wg.Done()
+	for {
+		job, err := p.take()
+		if err != nil {
+			return
+		}
+		p.execute(job)
+	}
+}
+
+// take 取出下一个任务，超出 MinWorkers 的工作协程空闲超时后返回错误并退出
+func (p *Pool) take() (*Job, error) {
+	for {
+		ctx, cancel := context.WithTimeout(p.ctx, p.opts.IdleTimeout)
+		job, err := p.backlog.Take(ctx)
+		cancel()
+
+		p.mutex.Lock()
+		if err == nil {
+			p.busy++
+			// 取出任务后仍有积压时继续扩容
+			p.scaleLocked()
+			p.mutex.Unlock()
+			return job, nil
+		}
+		if err == context.DeadlineExceeded && p.workers <= p.opts.MinWorkers {
+			p.mutex.Unlock()
+			continue
+		}
+		p.workers--
+		p.mutex.Unlock()
+		return nil, err
+	}
+}
+
+func (p *Pool) execute(job *Job) {
+	defer func() {
+		p.mutex.Lock()
+		p.busy--
+		p.mutex.Unlock()
+	}()
+	// ShutdownNow 之后取出的任务不再执行
+	if p.ctx.Err() != nil {
+		job.fail(ErrPoolClosed)
+		p.failed.Add(1)
+		return
+	}
+
+	start := time.Now()
+	p.waitTotal.Add(int64(start.Sub(job.submitted)))
+
+	ctx, cancel := p.ctx, context.CancelFunc(func() {})
+	timedOut := func() bool { return false }
+	if p.opts.TaskTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, p.opts.TaskTimeout)
+		// 任务不响应 ctx 时也在超时的时刻结束 Future，但工作协程要等任务返回后才能执行下一个任务
+		timer := time.AfterFunc(p.opts.TaskTimeout, func() { job.fail(context.DeadlineExceeded) })
+		timedOut = func() bool { return !timer.Stop() }
+	}
+	err := job.run(ctx)
+	if timedOut() && err == nil {
+		err = context.DeadlineExceeded
+	}
+	cancel()
+
+	p.runTotal.Add(int64(time.Since(start)))
+	p.executed.Add(1)
+	if err != nil {
+		p.failed.Add(1)
+	} else {
+		p.completed.Add(1)
+	}
+}
+
+// Stats returns the current pool statistics
+func (p *Pool) Stats() PoolStats {
+	p.mutex.Lock()
+	stats := PoolStats{Workers: p.workers, Busy: p.busy}
+	p.mutex.Unlock()
+
+	stats.Queued = p.backlog.Len()
+	stats.Submitted = p.submitted.Load()
+	stats.Completed = p.completed.Load()
+	stats.Failed = p.failed.Load()
+	if executed := p.executed.Load(); executed > 0 {
+		stats.AvgWait = time.Duration(p.waitTotal.Load() / int64(executed))
+		stats.AvgRun = time.Duration(p.runTotal.Load() / int64(executed))
+	}
+	return stats
+}
+
+// Shutdown stops accepting tasks and waits for the queued ones to finish.
+// If ctx is done first, running tasks are cancelled, queued tasks fail with ErrPoolClosed
+// and ctx.Err() is returned
+func (p *Pool) Shutdown(ctx context.Context) error {
+	p.close()
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		p.cancel()
+		return nil
+	case <-ctx.Done():
+		p.ShutdownNow()
+		return ctx.Err()
+	}
+}
+
+// ShutdownNow stops accepting tasks, cancels running tasks, fails queued tasks with ErrPoolClosed
+// and waits for the workers to exit
+func (p *Pool) ShutdownNow() {
+	p.close()
+	p.cancel()
+	p.wg.Wait()
+
+	// 已取消的 context 下 Take 仍会先返回队列中剩余的任务
+	for {
+		job, err := p.backlog.Take(p.ctx)
+		if err != nil {
+			return
+		}
+		job.fail(ErrPoolClosed)
+		p.failed.Add(1)
+	}
+}
+
+func (p *Pool) close() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if !p.closed {
+		p.closed = true
+		p.backlog.Close()
+	}
+}
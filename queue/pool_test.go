@@ -0,0 +1,185 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPoolSubmitFunc(t *testing.T) {
+	ctx := context.Background()
+	p := NewPool(PoolOptions{MinWorkers: 2})
+	defer p.ShutdownNow()
+
+	f, err := SubmitFunc(ctx, p, 0, func(ctx context.Context) (int, error) {
+		return 42, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, err := f.Wait(ctx); v != 42 || err != nil {
+		t.Fatalf("Wait: got %d %v, want 42", v, err)
+	}
+
+	errFailed := errors.New("failed")
+	failed, _ := p.Submit(ctx, func(ctx context.Context) error { return errFailed })
+	if _, err := failed.Wait(ctx); err != errFailed {
+		t.Fatalf("Wait: got %v, want %v", err, errFailed)
+	}
+	panicked, _ := p.Submit(ctx, func(ctx context.Context) error { panic("boom") })
+	if _, err := panicked.Wait(ctx); err == nil {
+		t.Fatal("a panicking task should fail")
+	}
+
+	stats := p.Stats()
+	if stats.Submitted != 3 || stats.Completed != 1 || stats.Failed != 2 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
+
+func TestPoolTaskTimeout(t *testing.T) {
+	p := NewPool(PoolOptions{TaskTimeout: 10 * time.Millisecond})
+	defer p.ShutdownNow()
+	f, _ := p.Submit(context.Background(), func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if _, err := f.Wait(context.Background()); err != context.DeadlineExceeded {
+		t.Fatalf("Wait: got %v, want DeadlineExceeded", err)
+	}
+}
+
+func TestPoolTaskTimeoutUncooperative(t *testing.T) {
+	p := NewPool(PoolOptions{TaskTimeout: 10 * time.Millisecond})
+	defer p.ShutdownNow()
+	release := make(chan struct{})
+	defer close(release)
+	f, _ := p.Submit(context.Background(), func(ctx context.Context) error {
+		<-release // 不响应 ctx
+		return nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := f.Wait(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("Wait: got %v, want DeadlineExceeded from the task timeout", err)
+	}
+	if ctx.Err() != nil {
+		t.Fatal("Wait returned only when its own context expired")
+	}
+}
+
+func TestPoolPriorityBacklog(t *testing.T) {
+	ctx := context.Background()
+	p := NewPool(PoolOptions{Backlog: NewBlockingPriorityQueue(JobLess)})
+	defer p.ShutdownNow()
+
+	// 第一个任务占住唯一的工作协程，其余任务在队列中按优先级排序
+	release := make(chan struct{})
+	_, _ = p.Submit(ctx, func(ctx context.Context) error {
+		<-release
+		return nil
+	})
+	eventually(t, func() bool { return p.Stats().Busy == 1 })
+
+	var mutex sync.Mutex
+	var order []int
+	var futures []*Future[struct{}]
+	for _, priority := range []int{1, 3, 2} {
+		priority := priority
+		f, _ := SubmitFunc(ctx, p, priority, func(ctx context.Context) (struct{}, error) {
+			mutex.Lock()
+			order = append(order, priority)
+			mutex.Unlock()
+			return struct{}{}, nil
+		})
+		futures = append(futures, f)
+	}
+	close(release)
+	for _, f := range futures {
+		_, _ = f.Wait(ctx)
+	}
+	if order[0] != 3 || order[1] != 2 || order[2] != 1 {
+		t.Fatalf("execution order %v, want [3 2 1]", order)
+	}
+}
+
+func TestPoolScalesUpAndDown(t *testing.T) {
+	ctx := context.Background()
+	p := NewPool(PoolOptions{MinWorkers: 1, MaxWorkers: 4, IdleTimeout: 10 * time.Millisecond})
+	defer p.ShutdownNow()
+
+	release := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		_, _ = p.Submit(ctx, func(ctx context.Context) error {
+			<-release
+			return nil
+		})
+	}
+	eventually(t, func() bool { return p.Stats().Busy == 4 })
+	if workers := p.Stats().Workers; workers != 4 {
+		t.Fatalf("Workers: got %d, want 4", workers)
+	}
+	close(release)
+	eventually(t, func() bool { return p.Stats().Workers == 1 })
+}
+
+func TestPoolShutdownRunsQueuedTasks(t *testing.T) {
+	ctx := context.Background()
+	p := NewPool(PoolOptions{})
+	var ran atomic.Int32
+	for i := 0; i < 10; i++ {
+		_, _ = p.Submit(ctx, func(ctx context.Context) error {
+			ran.Add(1)
+			return nil
+		})
+	}
+	if err := p.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if ran.Load() != 10 {
+		t.Fatalf("%d of 10 queued tasks ran before Shutdown returned", ran.Load())
+	}
+	if _, err := p.Submit(ctx, func(ctx context.Context) error { return nil }); err != ErrPoolClosed {
+		t.Fatalf("Submit after Shutdown: got %v, want ErrPoolClosed", err)
+	}
+}
+
+func TestPoolShutdownNow(t *testing.T) {
+	ctx := context.Background()
+	p := NewPool(PoolOptions{})
+
+	running, _ := p.Submit(ctx, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	eventually(t, func() bool { return p.Stats().Busy == 1 })
+	var queued []*Future[struct{}]
+	for i := 0; i < 3; i++ {
+		f, _ := p.Submit(ctx, func(ctx context.Context) error { return nil })
+		queued = append(queued, f)
+	}
+	const runFor = 20 * time.Millisecond
+	time.Sleep(runFor)
+	p.ShutdownNow()
+
+	if _, err := running.Wait(ctx); err != context.Canceled {
+		t.Fatalf("running task: got %v, want Canceled", err)
+	}
+	for _, f := range queued {
+		if _, err := f.Wait(ctx); err != ErrPoolClosed {
+			t.Fatalf("queued task: got %v, want ErrPoolClosed", err)
+		}
+	}
+	stats := p.Stats()
+	if stats.Failed != 4 {
+		t.Fatalf("Failed: got %d, want 4", stats.Failed)
+	}
+	// 未执行的任务不计入平均值
+	if stats.AvgRun < runFor {
+		t.Fatalf("AvgRun: got %v, want at least %v", stats.AvgRun, runFor)
+	}
+}
@@ -0,0 +1,366 @@
+package queue
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// 优先级队列，基于二叉堆实现，less 返回 true 的元素优先出队
+
+// heapInterface 堆调整所需的操作
+type heapInterface interface {
+	less(i, j int) bool
+	swap(i, j int)
+}
+
+func heapUp(h heapInterface, j int) {
+	for {
+		i := (j - 1) / 2
+		if i == j || !h.less(j, i) {
+			break
+		}
+		h.swap(i, j)
+		j = i
+	}
+}
+
+func heapDown(h heapInterface, i0, n int) bool {
+	i := i0
+	for {
+		j1 := 2*i + 1
+		if j1 >= n || j1 < 0 {
+			break
+		}
+		// 取左右子节点中优先级较高的一个
+		j := j1
+		if j2 := j1 + 1; j2 < n && h.less(j2, j1) {
+			j = j2
+		}
+		if !h.less(j, i) {
+			break
+		}
+		h.swap(i, j)
+		i = j
+	}
+	return i > i0
+}
+
+// heapFix 位置 i 的元素变化后恢复堆的性质
+func heapFix(h heapInterface, i, n int) {
+	if !heapDown(h, i, n) {
+		heapUp(h, i)
+	}
+}
+
+type PriorityQueue[T any] struct {
+	items []T
+	cmp   func(a, b T) bool
+}
+
+// NewPriorityQueue returns a queue where the element for which less reports true is popped first
+func NewPriorityQueue[T any](less func(a, b T) bool) *PriorityQueue[T] {
+	return &PriorityQueue[T]{cmp: less}
+}
+
+func (q *PriorityQueue[T]) less(i, j int) bool {
+	return q.cmp(q.items[i], q.items[j])
+}
+
+func (q *PriorityQueue[T]) swap(i, j int) {
+	q.items[i], q.items[j] = q.items[j], q.items[i]
+}
+
+func (q *PriorityQueue[T]) Len() int {
+	return len(q.items)
+}
+
+func (q *PriorityQueue[T]) IsEmpty() bool {
+	return len(q.items) == 0
+}
+
+// Push adds v to the queue in O(log n)
+func (q *PriorityQueue[T]) Push(v T) {
+	q.items = append(q.items, v)
+	heapUp(q, len(q.items)-1)
+}
+
+// Pop removes the element with the highest priority in O(log n)
+func (q *PriorityQueue[T]) Pop() (T, error) {
+	var zero T
+	if len(q.items) == 0 {
+		return zero, ErrEmpty
+	}
+	n := len(q.items) - 1
+	q.swap(0, n)
+	heapDown(q, 0, n)
+	v := q.items[n]
+	q.items[n] = zero
+	q.items = q.items[:n]
+	return v, nil
+}
+
+// Peek returns the element with the highest priority without removing it
+func (q *PriorityQueue[T]) Peek() (T, error) {
+	if len(q.items) == 0 {
+		var zero T
+		return zero, ErrEmpty
+	}
+	return q.items[0], nil
+}
+
+// Clear removes all elements from the queue
+func (q *PriorityQueue[T]) Clear() {
+	q.items = nil
+}
+
+// Handle 索引优先级队列中元素的句柄，用于更新或删除元素
+type Handle[T any] struct {
+	Value T
+
+	index int // 在堆中的下标，已出队时为 -1
+}
+
+// IndexedPriorityQueue 支持在 O(log n) 内更新和删除任意元素的优先级队列
+type IndexedPriorityQueue[T any] struct {
+	items []*Handle[T]
+	cmp   func(a, b T) bool
+}
+
+func NewIndexedPriorityQueue[T any](less func(a, b T) bool) *IndexedPriorityQueue[T] {
+	return &IndexedPriorityQueue[T]{cmp: less}
+}
+
+func (q *IndexedPriorityQueue[T]) less(i, j int) bool {
+	return q.cmp(q.items[i].Value, q.items[j].Value)
+}
+
+func (q *IndexedPriorityQueue[T]) swap(i, j int) {
+	q.items[i], q.items[j] = q.items[j], q.items[i]
+	q.items[i].index = i
+	q.items[j].index = j
+}
+
+func (q *IndexedPriorityQueue[T]) Len() int {
+	return len(q.items)
+}
+
+func (q *IndexedPriorityQueue[T]) IsEmpty() bool {
+	return len(q.items) == 0
+}
+
+// Push adds v to the queue and returns its handle
+func (q *IndexedPriorityQueue[T]) Push(v T) *Handle[T] {
+	h := &Handle[T]{Value: v, index: len(q.items)}
+	q.items = append(q.items, h)
+	heapUp(q, h.index)
+	return h
+}
+
+// Pop removes the element with the highest priority
+func (q *IndexedPriorityQueue[T]) Pop() (T, error) {
+	if len(q.items) == 0 {
+		var zero T
+		return zero, ErrEmpty
+	}
+	return q.removeAt(0).Value, nil
+}
+
+// Peek returns the handle of the element with the highest priority without removing it
+func (q *IndexedPriorityQueue[T]) Peek() (*Handle[T], error) {
+	if len(q.items) == 0 {
+		return nil, ErrEmpty
+	}
+	return q.items[0], nil
+}
+
+// Handles returns the handles of all elements in no particular order,
+// e.g. to find the handles again after the queue was decoded
+func (q *IndexedPriorityQueue[T]) Handles() []*Handle[T] {
+	return append([]*Handle[T](nil), q.items...)
+}
+
+// Contains reports whether h is still in the queue
+func (q *IndexedPriorityQueue[T]) Contains(h *Handle[T]) bool {
+	return h.index >= 0 && h.index < len(q.items) && q.items[h.index] == h
+}
+
+// Update replaces the value of h and restores its position in O(log n)
+func (q *IndexedPriorityQueue[T]) Update(h *Handle[T], v T) bool {
+	if !q.Contains(h) {
+		return false
+	}
+	h.Value = v
+	heapFix(q, h.index, len(q.items))
+	return true
+}
+
+// Fix restores the position of h after its value has been modified in place
+func (q *IndexedPriorityQueue[T]) Fix(h *Handle[T]) bool {
+	if !q.Contains(h) {
+		return false
+	}
+	heapFix(q, h.index, len(q.items))
+	return true
+}
+
+// Remove removes h from the queue in O(log n)
+func (q *IndexedPriorityQueue[T]) Remove(h *Handle[T]) bool {
+	if !q.Contains(h) {
+		return false
+	}
+	q.removeAt(h.index)
+	return true
+}
+
+// Clear removes all elements from the queue
+func (q *IndexedPriorityQueue[T]) Clear() {
+	for _, h := range q.items {
+		h.index = -1
+	}
+	q.items = nil
+}
+
+func (q *IndexedPriorityQueue[T]) removeAt(i int) *Handle[T] {
+	n := len(q.items) - 1
+	if n != i {
+		q.swap(i, n)
+		heapFix(q, i, n)
+	}
+	h := q.items[n]
+	q.items[n] = nil
+	q.items = q.items[:n]
+	h.index = -1
+	return h
+}
+
+// BlockingPriorityQueue 并发安全的优先级队列，Take 在队列为空时阻塞等待
+type BlockingPriorityQueue[T any] struct {
+	mutex    sync.Mutex
+	items    *PriorityQueue[timedItem[T]]
+	notEmpty signal
+	closed   bool
+	hook     Hook[T]
+}
+
+// timedItem 元素和它的入队时间，未设置 hook 时入队时间为零值
+type timedItem[T any] struct {
+	value    T
+	enqueued time.Time
+}
+
+func NewBlockingPriorityQueue[T any](less func(a, b T) bool) *BlockingPriorityQueue[T] {
+	return &BlockingPriorityQueue[T]{items: NewPriorityQueue(func(a, b timedItem[T]) bool {
+		return less(a.value, b.value)
+	})}
+}
+
+// SetHook reports the events of the queue to hook, a nil hook removes it.
+// Elements already in the queue are considered enqueued now
+func (q *BlockingPriorityQueue[T]) SetHook(hook Hook[T]) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.hook = hook
+	now := time.Now()
+	for i := range q.items.items {
+		q.items.items[i].enqueued = now
+	}
+}
+
+func (q *BlockingPriorityQueue[T]) Len() int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return q.items.Len()
+}
+
+// Put adds v to the queue, the queue is unbounded so Put never waits
+func (q *BlockingPriorityQueue[T]) Put(_ context.Context, v T) error {
+	q.mutex.Lock()
+	hook := q.hook
+	if q.closed {
+		q.mutex.Unlock()
+		if hook != nil {
+			hook.OnDrop(v, ErrClosed)
+		}
+		return ErrClosed
+	}
+	item := timedItem[T]{value: v}
+	if hook != nil {
+		item.enqueued = time.Now()
+	}
+	q.items.Push(item)
+	depth := q.items.Len()
+	q.notEmpty.broadcast()
+	q.mutex.Unlock()
+
+	if hook != nil {
+		hook.OnEnqueue(v, depth)
+	}
+	return nil
+}
+
+// Take removes the element with the highest priority, waiting for one until ctx is done.
+// Once the queue is closed the remaining elements can still be taken, then ErrClosed is returned
+func (q *BlockingPriorityQueue[T]) Take(ctx context.Context) (T, error) {
+	for {
+		q.mutex.Lock()
+		if !q.items.IsEmpty() {
+			item, _ := q.items.Pop()
+			hook, depth := q.hook, q.items.Len()
+			q.mutex.Unlock()
+			if hook != nil {
+				var wait time.Duration
+				if !item.enqueued.IsZero() {
+					wait = time.Since(item.enqueued)
+				}
+				hook.OnDequeue(item.value, wait, depth)
+			}
+			return item.value, nil
+		}
+		if q.closed {
+			q.mutex.Unlock()
+			var zero T
+			return zero, ErrClosed
+		}
+		wait := q.notEmpty.wait()
+		q.mutex.Unlock()
+
+		select {
+		case <-wait:
+		case <-ctx.Done():
+			var zero T
+			return zero, ctx.Err()
+		}
+	}
+}
+
+// Poll removes the element with the highest priority, waiting at most timeout for one.
+// ErrEmpty is returned if no element became available in time
+func (q *BlockingPriorityQueue[T]) Poll(timeout time.Duration) (T, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	v, err := q.Take(ctx)
+	if err == context.DeadlineExceeded {
+		return v, ErrEmpty
+	}
+	return v, err
+}
+
+// Peek returns the element with the highest priority without removing it
+func (q *BlockingPriorityQueue[T]) Peek() (T, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	item, err := q.items.Peek()
+	return item.value, err
+}
+
+// Close wakes up all waiting consumers, further Put calls fail with ErrClosed
+func (q *BlockingPriorityQueue[T]) Close() {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if q.closed {
+		return
+	}
+	q.closed = true
+	q.notEmpty.broadcast()
+}
@@ -0,0 +1,97 @@
+package queue
+
+import (
+	"context"
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+)
+
+func intLess(a, b int) bool { return a < b }
+
+func TestPriorityQueueOrder(t *testing.T) {
+	q := NewPriorityQueue(intLess)
+	values := rand.New(rand.NewSource(1)).Perm(200)
+	for _, v := range values {
+		q.Push(v)
+	}
+	for i := 0; i < 200; i++ {
+		v, err := q.Pop()
+		if err != nil || v != i {
+			t.Fatalf("Pop: got %d %v, want %d", v, err, i)
+		}
+	}
+	if _, err := q.Pop(); err != ErrEmpty {
+		t.Fatalf("Pop on an empty queue: got %v, want ErrEmpty", err)
+	}
+}
+
+func TestIndexedPriorityQueueUpdateRemove(t *testing.T) {
+	q := NewIndexedPriorityQueue(intLess)
+	handles := make(map[int]*Handle[int])
+	for _, v := range []int{50, 10, 40, 20, 30} {
+		handles[v] = q.Push(v)
+	}
+
+	if !q.Update(handles[50], 5) {
+		t.Fatal("Update of a queued handle failed")
+	}
+	if !q.Remove(handles[20]) || q.Remove(handles[20]) {
+		t.Fatal("Remove should succeed exactly once")
+	}
+	handles[40].Value = 1
+	q.Fix(handles[40])
+
+	var got []int
+	for !q.IsEmpty() {
+		v, _ := q.Pop()
+		got = append(got, v)
+	}
+	want := []int{1, 5, 10, 30}
+	if !sort.IntsAreSorted(got) || len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+	if q.Contains(handles[10]) || q.Update(handles[10], 0) {
+		t.Fatal("a popped handle should no longer be in the queue")
+	}
+}
+
+func TestBlockingPriorityQueue(t *testing.T) {
+	ctx := context.Background()
+	q := NewBlockingPriorityQueue(intLess)
+
+	result := make(chan int, 1)
+	go func() {
+		v, err := q.Take(ctx)
+		if err != nil {
+			t.Error(err)
+		}
+		result <- v
+	}()
+	time.Sleep(10 * time.Millisecond)
+	_ = q.Put(ctx, 7)
+	if v := <-result; v != 7 {
+		t.Fatalf("Take: got %d, want 7", v)
+	}
+
+	_ = q.Put(ctx, 3)
+	_ = q.Put(ctx, 1)
+	q.Close()
+	if err := q.Put(ctx, 2); err != ErrClosed {
+		t.Fatalf("Put after Close: got %v, want ErrClosed", err)
+	}
+	for _, want := range []int{1, 3} {
+		if v, err := q.Take(ctx); err != nil || v != want {
+			t.Fatalf("Take: got %d %v, want %d", v, err, want)
+		}
+	}
+	if _, err := q.Take(ctx); err != ErrClosed {
+		t.Fatalf("Take on a closed empty queue: got %v, want ErrClosed", err)
+	}
+}
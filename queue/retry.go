@@ -0,0 +1,248 @@
+package queue
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 重试队列，处理失败的任务按照指数退避重新入队，超过最大尝试次数后进入死信队列
+
+// Backoff 指数退避策略，第 n 次重试的等待时间为 Initial * Multiplier^(n-1)，不超过 Max
+type Backoff struct {
+	Initial    time.Duration // 第一次重试前的等待时间，默认 100ms
+	Max        time.Duration // 最长等待时间，0 表示不限制
+	Multiplier float64       // 每次重试等待时间的倍数，默认 2
+	Jitter     float64       // 随机抖动的比例，取值 [0, 1]，等待时间在 [d*(1-Jitter), d] 之间随机
+}
+
+// Delay returns the wait time before the given retry attempt, starting at 1
+func (b Backoff) Delay(attempt int) time.Duration {
+	initial, multiplier := b.Initial, b.Multiplier
+	if initial <= 0 {
+		initial = 100 * time.Millisecond
+	}
+	if multiplier < 1 {
+		multiplier = 2
+	}
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	d := float64(initial) * math.Pow(multiplier, float64(attempt-1))
+	if b.Max > 0 && d > float64(b.Max) {
+		d = float64(b.Max)
+	}
+	if d > math.MaxInt64 {
+		d = math.MaxInt64
+	}
+	if b.Jitter > 0 {
+		jitter := b.Jitter
+		if jitter > 1 {
+			jitter = 1
+		}
+		d -= d * jitter * rand.Float64()
+	}
+	return time.Duration(d)
+}
+
+type RetryOptions struct {
+	MaxAttempts int // 最大尝试次数（包括第一次），默认 3
+	Backoff     Backoff
+	Workers     int   // 并发处理的协程数，默认 1
+	Clock       Clock // 默认为 SystemClock
+}
+
+// DeadLetter 超过最大尝试次数的任务
+type DeadLetter[T any] struct {
+	Value    T
+	Attempts int
+	Err      error // 最后一次失败的错误
+	FailedAt time.Time
+}
+
+// RetryStats 重试队列的计数
+type RetryStats struct {
+	Pending   int   // 等待处理或等待重试的任务数
+	InFlight  int64 // 正在处理的任务数
+	Succeeded int64 // 处理成功的任务数
+	Retried   int64 // 重新入队的次数
+	Dead      int   // 死信队列中的任务数
+}
+
+type retryTask[T any] struct {
+	value    T
+	attempts int
+	err      error // 最后一次失败的错误
+}
+
+type RetryQueue[T any] struct {
+	handler func(ctx context.Context, v T) error
+	opts    RetryOptions
+	ready   *BlockingQueue[*retryTask[T]]
+	delayed *DelayQueue[*retryTask[T]]
+
+	mutex sync.Mutex
+	dead  []DeadLetter[T]
+
+	inFlight  atomic.Int64
+	succeeded atomic.Int64
+	retried   atomic.Int64
+}
+
+// NewRetryQueue returns a queue calling handler for every element until it succeeds or
+// MaxAttempts is reached, panics in handler count as failures
+func NewRetryQueue[T any](handler func(ctx context.Context, v T) error, opts RetryOptions) *RetryQueue[T] {
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 3
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = 1
+	}
+	if opts.Clock == nil {
+		opts.Clock = SystemClock
+	}
+	return &RetryQueue[T]{
+		handler: handler,
+		opts:    opts,
+		ready:   NewBlockingQueue[*retryTask[T]](0),
+		delayed: NewDelayQueueWithClock[*retryTask[T]](opts.Clock),
+	}
+}
+
+// Add enqueues v for processing
+func (q *RetryQueue[T]) Add(ctx context.Context, v T) error {
+	return q.ready.Put(ctx, &retryTask[T]{value: v})
+}
+
+// Run processes the queue until ctx is done or the queue is closed
+func (q *RetryQueue[T]) Run(ctx context.Context) error {
+	var wg sync.WaitGroup
+	wg.Add(q.opts.Workers + 1)
+	// 将到期的重试任务移回待处理队列
+	go func() {
+		defer wg.Done()
+		for {
+			task, err := q.delayed.Take(ctx)
+			if err != nil {
+				return
+			}
+			if err = q.ready.Put(ctx, task); err != nil {
+				// 队列已关闭，已取出的任务进入死信队列
+				q.bury(task)
+				return
+			}
+		}
+	}()
+	for i := 0; i < q.opts.Workers; i++ {
+		go func() {
+			defer wg.Done()
+			for {
+				task, err := q.ready.Take(ctx)
+				if err != nil {
+					return
+				}
+				q.process(ctx, task)
+			}
+		}()
+	}
+	wg.Wait()
+	return ctx.Err()
+}
+
+func (q *RetryQueue[T]) process(ctx context.Context, task *retryTask[T]) {
+	q.inFlight.Add(1)
+	_, err := callTask(ctx, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, q.handler(ctx, task.value)
+	})
+	q.inFlight.Add(-1)
+	if err == nil {
+		q.succeeded.Add(1)
+		return
+	}
+
+	task.attempts++
+	task.err = err
+	if task.attempts < q.opts.MaxAttempts {
+		if q.delayed.Put("", task, q.opts.Backoff.Delay(task.attempts)) == nil {
+			q.retried.Add(1)
+			return
+		}
+	}
+	q.bury(task)
+}
+
+// bury 将任务放入死信队列
+func (q *RetryQueue[T]) bury(tasks ...*retryTask[T]) {
+	now := q.opts.Clock.Now()
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	for _, task := range tasks {
+		q.dead = append(q.dead, DeadLetter[T]{
+			Value:    task.value,
+			Attempts: task.attempts,
+			Err:      task.err,
+			FailedAt: now,
+		})
+	}
+}
+
+// DeadLetters returns a copy of the dead letter queue
+func (q *RetryQueue[T]) DeadLetters() []DeadLetter[T] {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return append([]DeadLetter[T](nil), q.dead...)
+}
+
+// Replay moves the dead letters accepted by filter back to the queue with a fresh attempt count,
+// a nil filter replays all of them. It returns the number of replayed elements
+func (q *RetryQueue[T]) Replay(ctx context.Context, filter func(DeadLetter[T]) bool) (int, error) {
+	q.mutex.Lock()
+	var replay []DeadLetter[T]
+	kept := q.dead[:0]
+	for _, d := range q.dead {
+		if filter == nil || filter(d) {
+			replay = append(replay, d)
+		} else {
+			kept = append(kept, d)
+		}
+	}
+	q.dead = kept
+	q.mutex.Unlock()
+
+	for i, d := range replay {
+		if err := q.Add(ctx, d.Value); err != nil {
+			// 未能重新入队的任务放回死信队列
+			q.mutex.Lock()
+			q.dead = append(q.dead, replay[i:]...)
+			q.mutex.Unlock()
+			return i, err
+		}
+	}
+	return len(replay), nil
+}
+
+// Stats returns the current counters
+func (q *RetryQueue[T]) Stats() RetryStats {
+	q.mutex.Lock()
+	dead := len(q.dead)
+	q.mutex.Unlock()
+	return RetryStats{
+		Pending:   q.ready.Len() + q.delayed.Len(),
+		InFlight:  q.inFlight.Load(),
+		Succeeded: q.succeeded.Load(),
+		Retried:   q.retried.Load(),
+		Dead:      dead,
+	}
+}
+
+// Close stops accepting new elements and makes Run return once the workers are idle,
+// elements waiting for a retry are moved to the dead letter queue
+func (q *RetryQueue[T]) Close() {
+	q.ready.Close()
+	q.delayed.Close()
+	q.bury(q.delayed.Drain()...)
+}
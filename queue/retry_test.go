@@ -0,0 +1,145 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// eventually 轮询直到 cond 成立
+func eventually(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestBackoffDelay(t *testing.T) {
+	b := Backoff{Initial: time.Second, Max: 5 * time.Second, Multiplier: 2}
+	for attempt, want := range []time.Duration{time.Second, time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second} {
+		if d := b.Delay(attempt); d != want {
+			t.Fatalf("Delay(%d): got %v, want %v", attempt, d, want)
+		}
+	}
+	b.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if d := b.Delay(2); d < time.Second || d > 2*time.Second {
+			t.Fatalf("Delay with jitter out of range: %v", d)
+		}
+	}
+}
+
+func TestRetryQueueRetriesWithBackoff(t *testing.T) {
+	clock := newFakeClock()
+	start := clock.Now()
+	var calls atomic.Int32
+	q := NewRetryQueue(func(_ context.Context, v int) error {
+		if calls.Add(1) < 3 {
+			return errors.New("failed")
+		}
+		return nil
+	}, RetryOptions{
+		MaxAttempts: 3,
+		Backoff:     Backoff{Initial: time.Second, Multiplier: 2},
+		Clock:       clock,
+	})
+	done := make(chan error, 1)
+	go func() {
+		done <- q.Run(context.Background())
+	}()
+	_ = q.Add(context.Background(), 1)
+
+	clock.waitTimerAt(t, start.Add(time.Second))
+	clock.Advance(time.Second)
+	clock.waitTimerAt(t, start.Add(3*time.Second))
+	clock.Advance(2 * time.Second)
+	eventually(t, func() bool { return q.Stats().Succeeded == 1 })
+
+	stats := q.Stats()
+	if stats.Retried != 2 || stats.Dead != 0 || stats.Pending != 0 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+	q.Close()
+	<-done
+}
+
+func TestRetryQueueDeadLetters(t *testing.T) {
+	errFailed := errors.New("failed")
+	q := NewRetryQueue(func(_ context.Context, v int) error {
+		if v == 2 {
+			panic("boom")
+		}
+		return errFailed
+	}, RetryOptions{MaxAttempts: 1, Clock: newFakeClock()})
+	go q.Run(context.Background())
+	_ = q.Add(context.Background(), 1)
+	_ = q.Add(context.Background(), 2)
+	eventually(t, func() bool { return q.Stats().Dead == 2 })
+	q.Close()
+
+	dead := q.DeadLetters()
+	if dead[0].Value != 1 || dead[0].Err != errFailed || dead[0].Attempts != 1 {
+		t.Fatalf("unexpected dead letter %+v", dead[0])
+	}
+	if dead[1].Value != 2 || dead[1].Err == nil {
+		t.Fatalf("a panic should be recorded as a failure: %+v", dead[1])
+	}
+}
+
+func TestRetryQueueReplay(t *testing.T) {
+	var fail atomic.Bool
+	fail.Store(true)
+	q := NewRetryQueue(func(_ context.Context, v int) error {
+		if fail.Load() {
+			return errors.New("failed")
+		}
+		return nil
+	}, RetryOptions{MaxAttempts: 1, Clock: newFakeClock()})
+	go q.Run(context.Background())
+	defer q.Close()
+	for i := 0; i < 3; i++ {
+		_ = q.Add(context.Background(), i)
+	}
+	eventually(t, func() bool { return q.Stats().Dead == 3 })
+
+	fail.Store(false)
+	n, err := q.Replay(context.Background(), func(d DeadLetter[int]) bool { return d.Value != 1 })
+	if n != 2 || err != nil {
+		t.Fatalf("Replay: got %d %v, want 2", n, err)
+	}
+	eventually(t, func() bool { return q.Stats().Succeeded == 2 })
+	if dead := q.DeadLetters(); len(dead) != 1 || dead[0].Value != 1 {
+		t.Fatalf("dead letters after Replay: %+v", dead)
+	}
+}
+
+func TestRetryQueueCloseKeepsPendingRetries(t *testing.T) {
+	clock := newFakeClock()
+	errFailed := errors.New("failed")
+	q := NewRetryQueue(func(_ context.Context, v int) error {
+		return errFailed
+	}, RetryOptions{MaxAttempts: 3, Clock: clock})
+	done := make(chan error, 1)
+	go func() {
+		done <- q.Run(context.Background())
+	}()
+	_ = q.Add(context.Background(), 1)
+	eventually(t, func() bool { return q.Stats().Retried == 1 })
+
+	q.Close()
+	<-done
+	stats := q.Stats()
+	if stats.Pending != 0 || stats.Dead != 1 {
+		t.Fatalf("a task waiting for a retry was lost on Close: %+v", stats)
+	}
+	dead := q.DeadLetters()
+	if dead[0].Value != 1 || dead[0].Attempts != 1 || dead[0].Err != errFailed {
+		t.Fatalf("unexpected dead letter %+v", dead[0])
+	}
+}
@@ -0,0 +1,179 @@
+package queue
+
+import (
+	"sync/atomic"
+)
+
+// 无锁环形缓冲区，容量向上取整为 2 的幂，读写下标之间填充缓存行避免伪共享
+
+const cacheLineSize = 64
+
+type cacheLinePad [cacheLineSize]byte
+
+const maxRingSize = 1 << 62
+
+// roundUpPow2 返回不小于 n 的最小的 2 的幂，n 小于 1 时返回 1
+func roundUpPow2(n int) uint64 {
+	if n > maxRingSize {
+		panic("queue: ring size exceeds 1<<62")
+	}
+	size := uint64(1)
+	for n > 0 && size < uint64(n) {
+		size <<= 1
+	}
+	return size
+}
+
+// SPSCRing 单生产者单消费者的无锁环形缓冲区
+// 同一时刻只能有一个协程调用 TryPush，一个协程调用 TryPop
+type SPSCRing[T any] struct {
+	_    cacheLinePad
+	head atomic.Uint64 // 下一个读取的位置，只由消费者修改
+	_    cacheLinePad
+	tail atomic.Uint64 // 下一个写入的位置，只由生产者修改
+	_    cacheLinePad
+	mask uint64
+	buf  []T
+}
+
+// NewSPSCRing returns a ring holding at least size elements, at least 1.
+// It panics if size is larger than 1<<62
+func NewSPSCRing[T any](size int) *SPSCRing[T] {
+	n := roundUpPow2(size)
+	return &SPSCRing[T]{
+		mask: n - 1,
+		buf:  make([]T, n),
+	}
+}
+
+// Cap returns the capacity of the ring
+func (r *SPSCRing[T]) Cap() int {
+	return len(r.buf)
+}
+
+// Len returns the number of elements in the ring, it is only a snapshot under concurrent use
+func (r *SPSCRing[T]) Len() int {
+	return int(r.tail.Load() - r.head.Load())
+}
+
+// TryPush adds v to the ring without blocking, it returns false if the ring is full
+func (r *SPSCRing[T]) TryPush(v T) bool {
+	tail := r.tail.Load()
+	if tail-r.head.Load() == uint64(len(r.buf)) {
+		return false
+	}
+	r.buf[tail&r.mask] = v
+	r.tail.Store(tail + 1)
+	return true
+}
+
+// TryPop removes the oldest element without blocking, it returns false if the ring is empty
+func (r *SPSCRing[T]) TryPop() (T, bool) {
+	var zero T
+	head := r.head.Load()
+	if head == r.tail.Load() {
+		return zero, false
+	}
+	v := r.buf[head&r.mask]
+	r.buf[head&r.mask] = zero
+	r.head.Store(head + 1)
+	return v, true
+}
+
+type mpmcSlot[T any] struct {
+	// seq 等于写入位置时可写，等于写入位置+1 时可读
+	seq atomic.Uint64
+	val T
+}
+
+// MPMCRing 多生产者多消费者的无锁环形缓冲区（Dmitry Vyukov 的有界队列算法）
+type MPMCRing[T any] struct {
+	_     cacheLinePad
+	enq   atomic.Uint64
+	_     cacheLinePad
+	deq   atomic.Uint64
+	_     cacheLinePad
+	mask  uint64
+	slots []mpmcSlot[T]
+}
+
+// NewMPMCRing returns a ring holding at least size elements, at least 2.
+// It panics if size is larger than 1<<62
+func NewMPMCRing[T any](size int) *MPMCRing[T] {
+	n := roundUpPow2(size)
+	if n < 2 {
+		n = 2
+	}
+	r := &MPMCRing[T]{
+		mask:  n - 1,
+		slots: make([]mpmcSlot[T], n),
+	}
+	for i := range r.slots {
+		r.slots[i].seq.Store(uint64(i))
+	}
+	return r
+}
+
+// Cap returns the capacity of the ring
+func (r *MPMCRing[T]) Cap() int {
+	return len(r.slots)
+}
+
+// Len returns the number of elements in the ring, it is only a snapshot under concurrent use
+func (r *MPMCRing[T]) Len() int {
+	deq := r.deq.Load()
+	enq := r.enq.Load()
+	if enq < deq {
+		return 0
+	}
+	return int(enq - deq)
+}
+
+// TryPush adds v to the ring without blocking, it returns false if the ring is full
+func (r *MPMCRing[T]) TryPush(v T) bool {
+	pos := r.enq.Load()
+	for {
+		slot := &r.slots[pos&r.mask]
+		diff := int64(slot.seq.Load() - pos)
+		switch {
+		case diff == 0:
+			if r.enq.CompareAndSwap(pos, pos+1) {
+				slot.val = v
+				slot.seq.Store(pos + 1)
+				return true
+			}
+			pos = r.enq.Load()
+		case diff < 0:
+			// 该位置的元素还未被取走，队列已满
+			return false
+		default:
+			// 其他生产者已经占用了该位置
+			pos = r.enq.Load()
+		}
+	}
+}
+
+// TryPop removes the oldest element without blocking, it returns false if the ring is empty
+func (r *MPMCRing[T]) TryPop() (T, bool) {
+	var zero T
+	pos := r.deq.Load()
+	for {
+		slot := &r.slots[pos&r.mask]
+		diff := int64(slot.seq.Load() - (pos + 1))
+		switch {
+		case diff == 0:
+			if r.deq.CompareAndSwap(pos, pos+1) {
+				v := slot.val
+				slot.val = zero
+				slot.seq.Store(pos + r.mask + 1)
+				return v, true
+			}
+			pos = r.deq.Load()
+		case diff < 0:
+			// 该位置还未写入，队列为空
+			return zero, false
+		default:
+			pos = r.deq.Load()
+		}
+	}
+}
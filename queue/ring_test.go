@@ -0,0 +1,203 @@
+package queue
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+)
+
+func TestSPSCRingFIFO(t *testing.T) {
+	r := NewSPSCRing[int](5)
+	if r.Cap() != 8 {
+		t.Fatalf("Cap: got %d, want 8", r.Cap())
+	}
+	for i := 0; i < 8; i++ {
+		if !r.TryPush(i) {
+			t.Fatalf("TryPush %d on a ring with space failed", i)
+		}
+	}
+	if r.TryPush(8) {
+		t.Fatal("TryPush on a full ring succeeded")
+	}
+	if _, ok := r.TryPop(); !ok {
+		t.Fatal("TryPop on a full ring failed")
+	}
+	_ = r.TryPush(8)
+
+	for i := 1; i <= 8; i++ {
+		v, ok := r.TryPop()
+		if !ok || v != i {
+			t.Fatalf("TryPop: got %d %v, want %d", v, ok, i)
+		}
+	}
+	if _, ok := r.TryPop(); ok {
+		t.Fatal("TryPop on an empty ring succeeded")
+	}
+}
+
+func TestSPSCRingConcurrent(t *testing.T) {
+	const n = 100000
+	r := NewSPSCRing[int](64)
+	go func() {
+		for i := 0; i < n; i++ {
+			for !r.TryPush(i) {
+				runtime.Gosched()
+			}
+		}
+	}()
+	for i := 0; i < n; i++ {
+		v, ok := r.TryPop()
+		for !ok {
+			runtime.Gosched()
+			v, ok = r.TryPop()
+		}
+		if v != i {
+			t.Fatalf("TryPop: got %d, want %d", v, i)
+		}
+	}
+}
+
+func TestMPMCRingExactlyOnce(t *testing.T) {
+	const producers, consumers, perProducer = 4, 4, 20000
+	r := NewMPMCRing[int](128)
+
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			for i := 0; i < perProducer; i++ {
+				for !r.TryPush(p*perProducer + i) {
+					runtime.Gosched()
+				}
+			}
+		}(p)
+	}
+
+	// 每个消费者单独记录，结束后再合并检查
+	results := make([][]int, consumers)
+	var remaining sync.WaitGroup
+	remaining.Add(producers * perProducer)
+	done := make(chan struct{})
+	go func() {
+		remaining.Wait()
+		close(done)
+	}()
+	var cwg sync.WaitGroup
+	for c := 0; c < consumers; c++ {
+		cwg.Add(1)
+		go func(c int) {
+			defer cwg.Done()
+			for {
+				v, ok := r.TryPop()
+				if ok {
+					results[c] = append(results[c], v)
+					remaining.Done()
+					continue
+				}
+				select {
+				case <-done:
+					return
+				default:
+					runtime.Gosched()
+				}
+			}
+		}(c)
+	}
+
+	wg.Wait()
+	cwg.Wait()
+	seen := make([]int, producers*perProducer)
+	for _, values := range results {
+		for _, v := range values {
+			seen[v]++
+		}
+	}
+	for v, count := range seen {
+		if count != 1 {
+			t.Fatalf("value %d taken %d times", v, count)
+		}
+	}
+	if r.Len() != 0 {
+		t.Fatalf("Len after draining: got %d, want 0", r.Len())
+	}
+}
+
+func BenchmarkSPSCRing(b *testing.B) {
+	r := NewSPSCRing[int](1024)
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < b.N; i++ {
+			for !r.TryPush(i) {
+				runtime.Gosched()
+			}
+		}
+		close(done)
+	}()
+	for i := 0; i < b.N; i++ {
+		for {
+			if _, ok := r.TryPop(); ok {
+				break
+			}
+			runtime.Gosched()
+		}
+	}
+	<-done
+}
+
+func BenchmarkSPSCChannel(b *testing.B) {
+	ch := make(chan int, 1024)
+	go func() {
+		for i := 0; i < b.N; i++ {
+			ch <- i
+		}
+	}()
+	for i := 0; i < b.N; i++ {
+		<-ch
+	}
+}
+
+func BenchmarkMPMCRing(b *testing.B) {
+	r := NewMPMCRing[int](1024)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			for !r.TryPush(1) {
+				runtime.Gosched()
+			}
+			for {
+				if _, ok := r.TryPop(); ok {
+					break
+				}
+				runtime.Gosched()
+			}
+		}
+	})
+}
+
+func BenchmarkMPMCChannel(b *testing.B) {
+	ch := make(chan int, 1024)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			ch <- 1
+			<-ch
+		}
+	})
+}
+
+func TestRingSizes(t *testing.T) {
+	for _, size := range []int{-1, 0, 1, 3, 1024} {
+		want := int(roundUpPow2(size))
+		if r := NewSPSCRing[int](size); r.Cap() != want || r.Cap() < 1 {
+			t.Fatalf("NewSPSCRing(%d).Cap(): got %d, want %d", size, r.Cap(), want)
+		}
+		if r := NewMPMCRing[int](size); r.Cap() < 2 || r.Cap() < want {
+			t.Fatalf("NewMPMCRing(%d).Cap(): got %d", size, r.Cap())
+		}
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("a size above 1<<62 should panic")
+		}
+	}()
+	roundUpPow2(maxRingSize + 1)
+}
@@ -0,0 +1,21 @@
+package queue
+
+// signal 可以配合 select 使用的条件变量，调用方需要持有外部的锁
+// 等待方通过 wait 获取当前的 channel，broadcast 关闭该 channel 唤醒所有等待方并换上新的 channel
+type signal struct {
+	ch chan struct{}
+}
+
+func (s *signal) wait() <-chan struct{} {
+	if s.ch == nil {
+		s.ch = make(chan struct{})
+	}
+	return s.ch
+}
+
+func (s *signal) broadcast() {
+	if s.ch != nil {
+		close(s.ch)
+		s.ch = nil
+	}
+}
@@ -0,0 +1,398 @@
+package queue
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// 跳表，按 key 有序存储，查找、插入、删除和按排名访问的复杂度均为 O(log n)
+// 每一层的指针记录跨度（跨过的第 0 层节点数），用于计算排名
+
+const (
+	skipListMaxLevel = 32
+	skipListP        = 0.25
+)
+
+// Ordered 支持 < 比较的类型
+type Ordered interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64 | ~string
+}
+
+// Compare returns -1, 0 or 1 depending on whether a is less than, equal to or greater than b
+func Compare[K Ordered](a, b K) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+type skipLink[K any, V any] struct {
+	node *skipNode[K, V]
+	span int
+}
+
+type skipNode[K any, V any] struct {
+	key   K
+	value V
+	prev  *skipNode[K, V] // 第 0 层的前驱节点，用于反向遍历
+	next  []skipLink[K, V]
+}
+
+type SkipList[K any, V any] struct {
+	head  *skipNode[K, V]
+	tail  *skipNode[K, V]
+	level int
+	len   int
+	cmp   func(a, b K) int
+	rand  *rand.Rand
+}
+
+func NewSkipList[K Ordered, V any]() *SkipList[K, V] {
+	return NewSkipListFunc[K, V](Compare[K])
+}
+
+// NewSkipListFunc returns a skip list ordered by cmp,
+// which returns a negative number when a < b, 0 when a == b and a positive number when a > b
+func NewSkipListFunc[K any, V any](cmp func(a, b K) int) *SkipList[K, V] {
+	return &SkipList[K, V]{
+		head:  &skipNode[K, V]{next: make([]skipLink[K, V], skipListMaxLevel)},
+		level: 1,
+		cmp:   cmp,
+		rand:  rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+func (l *SkipList[K, V]) Len() int {
+	return l.len
+}
+
+func (l *SkipList[K, V]) randomLevel() int {
+	level := 1
+	for level < skipListMaxLevel && l.rand.Float64() < skipListP {
+		level++
+	}
+	return level
+}
+
+// Set stores value for key, it returns true if an existing value was replaced
+func (l *SkipList[K, V]) Set(key K, value V) bool {
+	var (
+		update [skipListMaxLevel]*skipNode[K, V]
+		rank   [skipListMaxLevel]int
+	)
+	x := l.head
+	for i := l.level - 1; i >= 0; i-- {
+		if i < l.level-1 {
+			rank[i] = rank[i+1]
+		}
+		for x.next[i].node != nil && l.cmp(x.next[i].node.key, key) < 0 {
+			rank[i] += x.next[i].span
+			x = x.next[i].node
+		}
+		update[i] = x
+	}
+	if next := x.next[0].node; next != nil && l.cmp(next.key, key) == 0 {
+		next.value = value
+		return true
+	}
+
+	level := l.randomLevel()
+	if level > l.level {
+		for i := l.level; i < level; i++ {
+			rank[i] = 0
+			update[i] = l.head
+			update[i].next[i].span = l.len
+		}
+		l.level = level
+	}
+
+	n := &skipNode[K, V]{key: key, value: value, next: make([]skipLink[K, V], level)}
+	for i := 0; i < level; i++ {
+		n.next[i].node = update[i].next[i].node
+		update[i].next[i].node = n
+		n.next[i].span = update[i].next[i].span - (rank[0] - rank[i])
+		update[i].next[i].span = rank[0] - rank[i] + 1
+	}
+	for i := level; i < l.level; i++ {
+		update[i].next[i].span++
+	}
+
+	if update[0] != l.head {
+		n.prev = update[0]
+	}
+	if n.next[0].node != nil {
+		n.next[0].node.prev = n
+	} else {
+		l.tail = n
+	}
+	l.len++
+	return false
+}
+
+// Get returns the value stored for key
+func (l *SkipList[K, V]) Get(key K) (V, bool) {
+	if n := l.seek(key); n != nil && l.cmp(n.key, key) == 0 {
+		return n.value, true
+	}
+	var zero V
+	return zero, false
+}
+
+// Delete removes key, it returns false if key was not present
+func (l *SkipList[K, V]) Delete(key K) bool {
+	var update [skipListMaxLevel]*skipNode[K, V]
+	x := l.head
+	for i := l.level - 1; i >= 0; i-- {
+		for x.next[i].node != nil && l.cmp(x.next[i].node.key, key) < 0 {
+			x = x.next[i].node
+		}
+		update[i] = x
+	}
+	x = x.next[0].node
+	if x == nil || l.cmp(x.key, key) != 0 {
+		return false
+	}
+
+	for i := 0; i < l.level; i++ {
+		if update[i].next[i].node == x {
+			update[i].next[i].span += x.next[i].span - 1
+			update[i].next[i].node = x.next[i].node
+		} else {
+			update[i].next[i].span--
+		}
+	}
+	if next := x.next[0].node; next != nil {
+		next.prev = x.prev
+	} else {
+		l.tail = x.prev
+	}
+	for l.level > 1 && l.head.next[l.level-1].node == nil {
+		l.level--
+	}
+	l.len--
+	return true
+}
+
+// seek 返回第一个 key 不小于 key 的节点
+func (l *SkipList[K, V]) seek(key K) *skipNode[K, V] {
+	x := l.head
+	for i := l.level - 1; i >= 0; i-- {
+		for x.next[i].node != nil && l.cmp(x.next[i].node.key, key) < 0 {
+			x = x.next[i].node
+		}
+	}
+	return x.next[0].node
+}
+
+// RankOf returns the 0-based position of key in ascending order
+func (l *SkipList[K, V]) RankOf(key K) (int, bool) {
+	rank := 0
+	x := l.head
+	for i := l.level - 1; i >= 0; i-- {
+		for x.next[i].node != nil && l.cmp(x.next[i].node.key, key) <= 0 {
+			rank += x.next[i].span
+			x = x.next[i].node
+		}
+		if x != l.head && l.cmp(x.key, key) == 0 {
+			return rank - 1, true
+		}
+	}
+	return 0, false
+}
+
+// ByRank returns the element at the 0-based position rank in ascending order
+func (l *SkipList[K, V]) ByRank(rank int) (K, V, bool) {
+	if n := l.byRank(rank); n != nil {
+		return n.key, n.value, true
+	}
+	var (
+		k K
+		v V
+	)
+	return k, v, false
+}
+
+func (l *SkipList[K, V]) byRank(rank int) *skipNode[K, V] {
+	if rank < 0 || rank >= l.len {
+		return nil
+	}
+	target, traversed := rank+1, 0
+	x := l.head
+	for i := l.level - 1; i >= 0; i-- {
+		for x.next[i].node != nil && traversed+x.next[i].span <= target {
+			traversed += x.next[i].span
+			x = x.next[i].node
+		}
+		if traversed == target {
+			return x
+		}
+	}
+	return nil
+}
+
+// SkipListIterator 跳表的迭代器，迭代过程中修改跳表会导致结果不确定
+type SkipListIterator[K any, V any] struct {
+	node *skipNode[K, V]
+}
+
+// Valid reports whether the iterator points to an element
+func (it *SkipListIterator[K, V]) Valid() bool {
+	return it.node != nil
+}
+
+func (it *SkipListIterator[K, V]) Key() K {
+	return it.node.key
+}
+
+func (it *SkipListIterator[K, V]) Value() V {
+	return it.node.value
+}
+
+// Next moves to the next element in ascending order
+func (it *SkipListIterator[K, V]) Next() {
+	it.node = it.node.next[0].node
+}
+
+// Prev moves to the previous element in ascending order
+func (it *SkipListIterator[K, V]) Prev() {
+	it.node = it.node.prev
+}
+
+// Seek returns an iterator at the first element whose key is not less than key
+func (l *SkipList[K, V]) Seek(key K) *SkipListIterator[K, V] {
+	return &SkipListIterator[K, V]{node: l.seek(key)}
+}
+
+// First returns an iterator at the smallest element
+func (l *SkipList[K, V]) First() *SkipListIterator[K, V] {
+	return &SkipListIterator[K, V]{node: l.head.next[0].node}
+}
+
+// Last returns an iterator at the largest element
+func (l *SkipList[K, V]) Last() *SkipListIterator[K, V] {
+	return &SkipListIterator[K, V]{node: l.tail}
+}
+
+// Ascend calls fn for the elements with from <= key < to in ascending order until fn returns false
+func (l *SkipList[K, V]) Ascend(from, to K, fn func(key K, value V) bool) {
+	for n := l.seek(from); n != nil && l.cmp(n.key, to) < 0; n = n.next[0].node {
+		if !fn(n.key, n.value) {
+			return
+		}
+	}
+}
+
+// Descend calls fn for the elements with from >= key > to in descending order until fn returns false
+func (l *SkipList[K, V]) Descend(from, to K, fn func(key K, value V) bool) {
+	n := l.seek(from)
+	if n == nil {
+		n = l.tail
+	} else if l.cmp(n.key, from) > 0 {
+		n = n.prev
+	}
+	for ; n != nil && l.cmp(n.key, to) > 0; n = n.prev {
+		if !fn(n.key, n.value) {
+			return
+		}
+	}
+}
+
+// Range calls fn for every element in ascending order until fn returns false
+func (l *SkipList[K, V]) Range(fn func(key K, value V) bool) {
+	for n := l.head.next[0].node; n != nil; n = n.next[0].node {
+		if !fn(n.key, n.value) {
+			return
+		}
+	}
+}
+
+// ConcurrentSkipList 并发安全的跳表，读操作之间可以并发
+type ConcurrentSkipList[K any, V any] struct {
+	mutex sync.RWMutex
+	list  *SkipList[K, V]
+}
+
+func NewConcurrentSkipList[K Ordered, V any]() *ConcurrentSkipList[K, V] {
+	return &ConcurrentSkipList[K, V]{list: NewSkipList[K, V]()}
+}
+
+func NewConcurrentSkipListFunc[K any, V any](cmp func(a, b K) int) *ConcurrentSkipList[K, V] {
+	return &ConcurrentSkipList[K, V]{list: NewSkipListFunc[K, V](cmp)}
+}
+
+func (l *ConcurrentSkipList[K, V]) Len() int {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	return l.list.Len()
+}
+
+func (l *ConcurrentSkipList[K, V]) Set(key K, value V) bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.list.Set(key, value)
+}
+
+func (l *ConcurrentSkipList[K, V]) Get(key K) (V, bool) {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	return l.list.Get(key)
+}
+
+func (l *ConcurrentSkipList[K, V]) Delete(key K) bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.list.Delete(key)
+}
+
+func (l *ConcurrentSkipList[K, V]) RankOf(key K) (int, bool) {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	return l.list.RankOf(key)
+}
+
+func (l *ConcurrentSkipList[K, V]) ByRank(rank int) (K, V, bool) {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	return l.list.ByRank(rank)
+}
+
+// Seek returns the first element whose key is not less than key
+func (l *ConcurrentSkipList[K, V]) Seek(key K) (K, V, bool) {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	if n := l.list.seek(key); n != nil {
+		return n.key, n.value, true
+	}
+	var (
+		k K
+		v V
+	)
+	return k, v, false
+}
+
+// Ascend holds the read lock while calling fn, fn must not modify the list
+func (l *ConcurrentSkipList[K, V]) Ascend(from, to K, fn func(key K, value V) bool) {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	l.list.Ascend(from, to, fn)
+}
+
+// Descend holds the read lock while calling fn, fn must not modify the list
+func (l *ConcurrentSkipList[K, V]) Descend(from, to K, fn func(key K, value V) bool) {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	l.list.Descend(from, to, fn)
+}
+
+// Range holds the read lock while calling fn, fn must not modify the list
+func (l *ConcurrentSkipList[K, V]) Range(fn func(key K, value V) bool) {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	l.list.Range(fn)
+}
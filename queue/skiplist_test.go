@@ -0,0 +1,165 @@
+package queue
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+	"testing"
+)
+
+// checkSkipList 将跳表与排序后的 map 逐项比较，覆盖排名、按排名访问、迭代器和范围遍历
+func checkSkipList(t *testing.T, r *rand.Rand, l *SkipList[int, int], want map[int]int) {
+	t.Helper()
+	keys := make([]int, 0, len(want))
+	for k := range want {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+
+	if l.Len() != len(keys) {
+		t.Fatalf("Len: got %d, want %d", l.Len(), len(keys))
+	}
+	for i, k := range keys {
+		if rank, ok := l.RankOf(k); !ok || rank != i {
+			t.Fatalf("RankOf(%d): got %d %v, want %d", k, rank, ok, i)
+		}
+		if key, v, ok := l.ByRank(i); !ok || key != k || v != want[k] {
+			t.Fatalf("ByRank(%d): got %d %d %v, want %d %d", i, key, v, ok, k, want[k])
+		}
+	}
+	if _, _, ok := l.ByRank(len(keys)); ok {
+		t.Fatalf("ByRank(%d) past the end succeeded", len(keys))
+	}
+	if _, _, ok := l.ByRank(-1); ok {
+		t.Fatal("ByRank(-1) succeeded")
+	}
+
+	i := 0
+	for it := l.First(); it.Valid(); it.Next() {
+		if it.Key() != keys[i] || it.Value() != want[keys[i]] {
+			t.Fatalf("Next: got %d at %d, want %d", it.Key(), i, keys[i])
+		}
+		i++
+	}
+	if i != len(keys) {
+		t.Fatalf("Next visited %d elements, want %d", i, len(keys))
+	}
+	for it := l.Last(); it.Valid(); it.Prev() {
+		i--
+		if it.Key() != keys[i] {
+			t.Fatalf("Prev: got %d at %d, want %d", it.Key(), i, keys[i])
+		}
+	}
+	if i != 0 {
+		t.Fatalf("Prev stopped at %d", i)
+	}
+
+	from, to := r.Intn(120)-10, r.Intn(120)-10
+	var got, expected []int
+	l.Ascend(from, to, func(k, _ int) bool {
+		got = append(got, k)
+		return true
+	})
+	for _, k := range keys {
+		if k >= from && k < to {
+			expected = append(expected, k)
+		}
+	}
+	expectInts(t, got, expected...)
+
+	got, expected = nil, nil
+	l.Descend(from, to, func(k, _ int) bool {
+		got = append(got, k)
+		return true
+	})
+	for j := len(keys) - 1; j >= 0; j-- {
+		if keys[j] <= from && keys[j] > to {
+			expected = append(expected, keys[j])
+		}
+	}
+	expectInts(t, got, expected...)
+
+	it := l.Seek(from)
+	j := sort.SearchInts(keys, from)
+	if j == len(keys) {
+		if it.Valid() {
+			t.Fatalf("Seek(%d) past the largest key returned %d", from, it.Key())
+		}
+	} else if !it.Valid() || it.Key() != keys[j] {
+		t.Fatalf("Seek(%d): got valid %v, want %d", from, it.Valid(), keys[j])
+	}
+}
+
+func TestSkipListRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	l := NewSkipList[int, int]()
+	want := make(map[int]int)
+	for round := 0; round < 200; round++ {
+		for op := 0; op < 20; op++ {
+			k := r.Intn(100)
+			if r.Intn(3) == 0 {
+				_, present := want[k]
+				if l.Delete(k) != present {
+					t.Fatalf("Delete(%d): got %v, want %v", k, !present, present)
+				}
+				delete(want, k)
+				continue
+			}
+			v := r.Int()
+			_, present := want[k]
+			if l.Set(k, v) != present {
+				t.Fatalf("Set(%d): replaced %v, want %v", k, !present, present)
+			}
+			want[k] = v
+		}
+		checkSkipList(t, r, l, want)
+		for k := 0; k < 100; k++ {
+			wv, present := want[k]
+			if v, ok := l.Get(k); ok != present || v != wv {
+				t.Fatalf("Get(%d): got %d %v, want %d %v", k, v, ok, wv, present)
+			}
+			if _, ok := l.RankOf(k); ok != present {
+				t.Fatalf("RankOf(%d): got %v, want %v", k, ok, present)
+			}
+		}
+	}
+}
+
+func TestSkipListFunc(t *testing.T) {
+	// 降序的跳表
+	l := NewSkipListFunc[int, string](func(a, b int) int { return Compare(b, a) })
+	for _, k := range []int{3, 1, 2} {
+		l.Set(k, "")
+	}
+	var got []int
+	l.Range(func(k int, _ string) bool {
+		got = append(got, k)
+		return true
+	})
+	expectInts(t, got, 3, 2, 1)
+	if rank, _ := l.RankOf(1); rank != 2 {
+		t.Fatalf("RankOf(1): got %d, want 2", rank)
+	}
+}
+
+func TestConcurrentSkipList(t *testing.T) {
+	l := NewConcurrentSkipList[int, int]()
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 250; i++ {
+				l.Set(g*250+i, i)
+				l.RankOf(i)
+			}
+		}(g)
+	}
+	wg.Wait()
+	if l.Len() != 1000 {
+		t.Fatalf("Len: got %d, want 1000", l.Len())
+	}
+	if k, _, ok := l.ByRank(500); !ok || k != 500 {
+		t.Fatalf("ByRank(500): got %d %v", k, ok)
+	}
+}
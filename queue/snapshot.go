@@ -0,0 +1,524 @@
+package queue
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// 队列的快照与序列化，快照按出队顺序复制当前的元素；
+// 序列化后的数据可以在重启后通过 json.Unmarshal 或 gob 解码恢复队列
+
+// queueState 序列化时保存的队列状态
+type queueState[T any] struct {
+	Cap   int  `json:"cap,omitempty"`
+	Grow  bool `json:"grow,omitempty"`
+	Items []T  `json:"items"`
+}
+
+func gobEncode(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func gobDecode(data []byte, v any) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// Snapshot returns a copy of the elements in FIFO order
+func (q *LoopQueue[T]) Snapshot() []T {
+	return q.items()
+}
+
+func (q *LoopQueue[T]) state() queueState[T] {
+	return queueState[T]{Cap: q.cap, Grow: q.grow, Items: q.items()}
+}
+
+func (q *LoopQueue[T]) restore(s queueState[T]) error {
+	n := len(s.Items)
+	size := s.Cap
+	if s.Grow {
+		if size < n {
+			size = n
+		}
+		if size < minGrowCap {
+			size = minGrowCap
+		}
+	} else if n > size {
+		return fmt.Errorf("queue: %d items exceed the capacity %d", n, size)
+	}
+
+	q.member = make([]T, size)
+	copy(q.member, s.Items)
+	q.len, q.cap, q.grow = n, size, s.Grow
+	q.front, q.rear = 0, 0
+	if size > 0 {
+		q.rear = n % size
+	}
+	resetEnqueueTimes(q.enqueued, n)
+	return nil
+}
+
+func (q *LoopQueue[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(q.state())
+}
+
+// UnmarshalJSON replaces the queue with the encoded one, including its capacity, the hook is kept
+func (q *LoopQueue[T]) UnmarshalJSON(data []byte) error {
+	var s queueState[T]
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	return q.restore(s)
+}
+
+func (q *LoopQueue[T]) GobEncode() ([]byte, error) {
+	return gobEncode(q.state())
+}
+
+func (q *LoopQueue[T]) GobDecode(data []byte) error {
+	var s queueState[T]
+	if err := gobDecode(data, &s); err != nil {
+		return err
+	}
+	return q.restore(s)
+}
+
+// Snapshot returns a copy of the elements in FIFO order
+func (q *SyncLoopQueue[T]) Snapshot() []T {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return q.queue.Snapshot()
+}
+
+// Snapshot returns a copy of the elements from front to back
+func (d *Deque[T]) Snapshot() []T {
+	return d.Values()
+}
+
+func (d *Deque[T]) restore(s queueState[T]) {
+	size := len(s.Items)
+	if size < minGrowCap {
+		size = minGrowCap
+	}
+	d.member = make([]T, size)
+	copy(d.member, s.Items)
+	d.head, d.len = 0, len(s.Items)
+}
+
+func (d *Deque[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(queueState[T]{Items: d.Values()})
+}
+
+func (d *Deque[T]) UnmarshalJSON(data []byte) error {
+	var s queueState[T]
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	d.restore(s)
+	return nil
+}
+
+func (d *Deque[T]) GobEncode() ([]byte, error) {
+	return gobEncode(queueState[T]{Items: d.Values()})
+}
+
+func (d *Deque[T]) GobDecode(data []byte) error {
+	var s queueState[T]
+	if err := gobDecode(data, &s); err != nil {
+		return err
+	}
+	d.restore(s)
+	return nil
+}
+
+// Snapshot returns a copy of the elements in the order they would be popped, in O(n log n)
+func (q *PriorityQueue[T]) Snapshot() []T {
+	tmp := &PriorityQueue[T]{items: append([]T(nil), q.items...), cmp: q.cmp}
+	items := make([]T, 0, len(q.items))
+	for !tmp.IsEmpty() {
+		v, _ := tmp.Pop()
+		items = append(items, v)
+	}
+	return items
+}
+
+func (q *PriorityQueue[T]) restore(s queueState[T]) error {
+	if q.cmp == nil {
+		return errors.New("queue: priority queue must be created by NewPriorityQueue before decoding")
+	}
+	q.items = s.Items
+	for i := len(q.items)/2 - 1; i >= 0; i-- {
+		heapDown(q, i, len(q.items))
+	}
+	return nil
+}
+
+func (q *PriorityQueue[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(queueState[T]{Items: q.Snapshot()})
+}
+
+// UnmarshalJSON replaces the elements of the queue, which must have been created by NewPriorityQueue
+func (q *PriorityQueue[T]) UnmarshalJSON(data []byte) error {
+	var s queueState[T]
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	return q.restore(s)
+}
+
+func (q *PriorityQueue[T]) GobEncode() ([]byte, error) {
+	return gobEncode(queueState[T]{Items: q.Snapshot()})
+}
+
+// GobDecode replaces the elements of the queue, which must have been created by NewPriorityQueue
+func (q *PriorityQueue[T]) GobDecode(data []byte) error {
+	var s queueState[T]
+	if err := gobDecode(data, &s); err != nil {
+		return err
+	}
+	return q.restore(s)
+}
+
+// Snapshot returns a copy of the elements in the order they would be popped, in O(n log n)
+func (q *IndexedPriorityQueue[T]) Snapshot() []T {
+	values := make([]T, len(q.items))
+	for i, h := range q.items {
+		values[i] = h.Value
+	}
+	// items 已经是堆序，可以直接按 PriorityQueue 出队
+	return (&PriorityQueue[T]{items: values, cmp: q.cmp}).Snapshot()
+}
+
+// restore 恢复后原有的句柄全部失效，需要通过 Handles 重新获取
+func (q *IndexedPriorityQueue[T]) restore(s queueState[T]) error {
+	if q.cmp == nil {
+		return errors.New("queue: indexed priority queue must be created by NewIndexedPriorityQueue before decoding")
+	}
+	q.Clear()
+	q.items = make([]*Handle[T], len(s.Items))
+	for i, v := range s.Items {
+		q.items[i] = &Handle[T]{Value: v, index: i}
+	}
+	for i := len(q.items)/2 - 1; i >= 0; i-- {
+		heapDown(q, i, len(q.items))
+	}
+	return nil
+}
+
+func (q *IndexedPriorityQueue[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(queueState[T]{Items: q.Snapshot()})
+}
+
+// UnmarshalJSON replaces the elements of the queue, which must have been created by NewIndexedPriorityQueue.
+// Handles obtained before are no longer part of the queue, use Handles to get the new ones
+func (q *IndexedPriorityQueue[T]) UnmarshalJSON(data []byte) error {
+	var s queueState[T]
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	return q.restore(s)
+}
+
+func (q *IndexedPriorityQueue[T]) GobEncode() ([]byte, error) {
+	return gobEncode(queueState[T]{Items: q.Snapshot()})
+}
+
+// GobDecode replaces the elements of the queue, which must have been created by NewIndexedPriorityQueue.
+// Handles obtained before are no longer part of the queue, use Handles to get the new ones
+func (q *IndexedPriorityQueue[T]) GobDecode(data []byte) error {
+	var s queueState[T]
+	if err := gobDecode(data, &s); err != nil {
+		return err
+	}
+	return q.restore(s)
+}
+
+// Snapshot returns a copy of the elements in the order they would be popped
+func (q *BlockingPriorityQueue[T]) Snapshot() []T {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	items := make([]T, 0, q.items.Len())
+	for _, item := range q.items.Snapshot() {
+		items = append(items, item.value)
+	}
+	return items
+}
+
+// restore 恢复后的元素以当前时间作为入队时间
+func (q *BlockingPriorityQueue[T]) restore(s queueState[T]) error {
+	if q.items == nil {
+		return errors.New("queue: blocking priority queue must be created by NewBlockingPriorityQueue before decoding")
+	}
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	var now time.Time
+	if q.hook != nil {
+		now = time.Now()
+	}
+	items := make([]timedItem[T], len(s.Items))
+	for i, v := range s.Items {
+		items[i] = timedItem[T]{value: v, enqueued: now}
+	}
+	if err := q.items.restore(queueState[timedItem[T]]{Items: items}); err != nil {
+		return err
+	}
+	q.notEmpty.broadcast()
+	return nil
+}
+
+func (q *BlockingPriorityQueue[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(queueState[T]{Items: q.Snapshot()})
+}
+
+// UnmarshalJSON replaces the elements of the queue, which must have been created by NewBlockingPriorityQueue
+func (q *BlockingPriorityQueue[T]) UnmarshalJSON(data []byte) error {
+	var s queueState[T]
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	return q.restore(s)
+}
+
+func (q *BlockingPriorityQueue[T]) GobEncode() ([]byte, error) {
+	return gobEncode(queueState[T]{Items: q.Snapshot()})
+}
+
+// GobDecode replaces the elements of the queue, which must have been created by NewBlockingPriorityQueue
+func (q *BlockingPriorityQueue[T]) GobDecode(data []byte) error {
+	var s queueState[T]
+	if err := gobDecode(data, &s); err != nil {
+		return err
+	}
+	return q.restore(s)
+}
+
+// Snapshot returns a copy of the elements in FIFO order
+func (q *BlockingQueue[T]) Snapshot() []T {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return q.items.Snapshot()
+}
+
+func (q *BlockingQueue[T]) state() queueState[T] {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return q.items.state()
+}
+
+// restore 恢复后的元素以当前时间作为入队时间
+func (q *BlockingQueue[T]) restore(s queueState[T]) error {
+	items := &LoopQueue[T]{}
+	if err := items.restore(s); err != nil {
+		return err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.items = items
+	resetEnqueueTimes(q.enqueued, items.Len())
+	q.notEmpty.broadcast()
+	q.notFull.broadcast()
+	return nil
+}
+
+func (q *BlockingQueue[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(q.state())
+}
+
+// UnmarshalJSON replaces the elements and capacity of the queue, the hook is kept
+func (q *BlockingQueue[T]) UnmarshalJSON(data []byte) error {
+	var s queueState[T]
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	return q.restore(s)
+}
+
+func (q *BlockingQueue[T]) GobEncode() ([]byte, error) {
+	return gobEncode(q.state())
+}
+
+// GobDecode replaces the elements and capacity of the queue, the hook is kept
+func (q *BlockingQueue[T]) GobDecode(data []byte) error {
+	var s queueState[T]
+	if err := gobDecode(data, &s); err != nil {
+		return err
+	}
+	return q.restore(s)
+}
+
+// Snapshot returns a copy of the elements of every partition in FIFO order
+func (q *FairQueue[K, T]) Snapshot() map[K][]T {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	snapshot := make(map[K][]T, len(q.partitions))
+	for key, p := range q.partitions {
+		snapshot[key] = p.items.Snapshot()
+	}
+	return snapshot
+}
+
+// fairState 序列化时保存的公平队列状态，分区按调度顺序排列
+type fairState[K comparable, T any] struct {
+	Partitions []fairPartitionState[K, T] `json:"partitions"`
+}
+
+type fairPartitionState[K comparable, T any] struct {
+	Key   K   `json:"key"`
+	Items []T `json:"items"`
+}
+
+func (q *FairQueue[K, T]) state() fairState[K, T] {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	s := fairState[K, T]{Partitions: make([]fairPartitionState[K, T], 0, q.active.Len())}
+	q.active.Range(func(p *fairPartition[K, T]) bool {
+		s.Partitions = append(s.Partitions, fairPartitionState[K, T]{Key: p.key, Items: p.items.Snapshot()})
+		return true
+	})
+	return s
+}
+
+// restore 调度状态（WRR 已取出的个数、DRR 的额度）从新一轮开始，恢复后的元素以当前时间作为入队时间
+func (q *FairQueue[K, T]) restore(s fairState[K, T]) error {
+	if q.partitions == nil {
+		return errors.New("queue: fair queue must be created by NewFairQueue before decoding")
+	}
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if capacity := q.opts.PartitionCapacity; capacity > 0 {
+		for _, ps := range s.Partitions {
+			if len(ps.Items) > capacity {
+				return fmt.Errorf("queue: %d items exceed the partition capacity %d", len(ps.Items), capacity)
+			}
+		}
+	}
+
+	q.partitions = make(map[K]*fairPartition[K, T], len(s.Partitions))
+	q.active.Clear()
+	q.len = 0
+	for _, ps := range s.Partitions {
+		for _, v := range ps.Items {
+			q.push(ps.Key, v)
+		}
+	}
+	q.notEmpty.broadcast()
+	q.notFull.broadcast()
+	return nil
+}
+
+func (q *FairQueue[K, T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(q.state())
+}
+
+// UnmarshalJSON replaces the partitions of the queue, which must have been created by NewFairQueue
+func (q *FairQueue[K, T]) UnmarshalJSON(data []byte) error {
+	var s fairState[K, T]
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	return q.restore(s)
+}
+
+func (q *FairQueue[K, T]) GobEncode() ([]byte, error) {
+	return gobEncode(q.state())
+}
+
+// GobDecode replaces the partitions of the queue, which must have been created by NewFairQueue
+func (q *FairQueue[K, T]) GobDecode(data []byte) error {
+	var s fairState[K, T]
+	if err := gobDecode(data, &s); err != nil {
+		return err
+	}
+	return q.restore(s)
+}
+
+// delayState 序列化时保存的延迟队列状态，元素按到期顺序排列
+type delayState[T any] struct {
+	Items []delayEntry[T] `json:"items"`
+}
+
+type delayEntry[T any] struct {
+	Key   string    `json:"key,omitempty"`
+	Value T         `json:"value"`
+	Due   time.Time `json:"due"`
+}
+
+// Snapshot returns a copy of the pending elements in due order
+func (q *DelayQueue[T]) Snapshot() []T {
+	s := q.state()
+	items := make([]T, len(s.Items))
+	for i, e := range s.Items {
+		items[i] = e.Value
+	}
+	return items
+}
+
+func (q *DelayQueue[T]) state() delayState[T] {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	s := delayState[T]{Items: make([]delayEntry[T], 0, q.items.Len())}
+	for _, item := range q.items.Snapshot() {
+		s.Items = append(s.Items, delayEntry[T]{Key: item.key, Value: item.value, Due: item.due})
+	}
+	return s
+}
+
+// restore 元素保留原来的到期时间，已经到期的元素可以立即取出
+func (q *DelayQueue[T]) restore(s delayState[T]) error {
+	if q.items == nil {
+		return errors.New("queue: delay queue must be created by NewDelayQueue before decoding")
+	}
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.items.Clear()
+	q.keys = make(map[string]*Handle[*delayItem[T]])
+	now := q.clock.Now()
+	for _, e := range s.Items {
+		q.seq++
+		item := &delayItem[T]{key: e.Key, value: e.Value, due: e.Due, seq: q.seq, put: now}
+		if h, ok := q.keys[e.Key]; ok && e.Key != "" {
+			q.items.Update(h, item)
+		} else {
+			h = q.items.Push(item)
+			if e.Key != "" {
+				q.keys[e.Key] = h
+			}
+		}
+	}
+	q.changed.broadcast()
+	return nil
+}
+
+func (q *DelayQueue[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(q.state())
+}
+
+// UnmarshalJSON replaces the pending elements of the queue, which must have been created by NewDelayQueue
+func (q *DelayQueue[T]) UnmarshalJSON(data []byte) error {
+	var s delayState[T]
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	return q.restore(s)
+}
+
+func (q *DelayQueue[T]) GobEncode() ([]byte, error) {
+	return gobEncode(q.state())
+}
+
+// GobDecode replaces the pending elements of the queue, which must have been created by NewDelayQueue
+func (q *DelayQueue[T]) GobDecode(data []byte) error {
+	var s delayState[T]
+	if err := gobDecode(data, &s); err != nil {
+		return err
+	}
+	return q.restore(s)
+}
@@ -0,0 +1,261 @@
+package queue
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+)
+
+func expectInts(t *testing.T, got []int, want ...int) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestLoopQueueSnapshotRoundTrip(t *testing.T) {
+	q := NewLoopQueue[int](4)
+	for i := 0; i < 4; i++ {
+		_ = q.Push(i)
+	}
+	_, _ = q.Pop()
+	_ = q.Push(4) // 元素跨越底层数组的末尾
+
+	data, err := json.Marshal(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded LoopQueue[int]
+	if err = json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	expectInts(t, decoded.Snapshot(), 1, 2, 3, 4)
+	if decoded.Cap() != 4 || !decoded.IsFull() {
+		t.Fatalf("capacity was not restored: Cap %d", decoded.Cap())
+	}
+
+	var buf bytes.Buffer
+	if err = gob.NewEncoder(&buf).Encode(q); err != nil {
+		t.Fatal(err)
+	}
+	var gobDecoded LoopQueue[int]
+	if err = gob.NewDecoder(&buf).Decode(&gobDecoded); err != nil {
+		t.Fatal(err)
+	}
+	expectInts(t, gobDecoded.Snapshot(), 1, 2, 3, 4)
+}
+
+func TestPriorityQueueSnapshotRoundTrip(t *testing.T) {
+	q := NewPriorityQueue(intLess)
+	for _, v := range []int{5, 1, 4, 2, 3} {
+		q.Push(v)
+	}
+	expectInts(t, q.Snapshot(), 1, 2, 3, 4, 5)
+	if q.Len() != 5 {
+		t.Fatal("Snapshot should not modify the queue")
+	}
+
+	data, _ := json.Marshal(q)
+	if err := json.Unmarshal(data, &PriorityQueue[int]{}); err == nil {
+		t.Fatal("decoding into a queue without a comparator should fail")
+	}
+	decoded := NewPriorityQueue(intLess)
+	if err := json.Unmarshal(data, decoded); err != nil {
+		t.Fatal(err)
+	}
+	v, _ := decoded.Pop()
+	expectInts(t, []int{v}, 1)
+}
+
+func TestIndexedPriorityQueueSnapshotRoundTrip(t *testing.T) {
+	q := NewIndexedPriorityQueue(intLess)
+	for _, v := range []int{5, 1, 4, 2, 3} {
+		q.Push(v)
+	}
+	expectInts(t, q.Snapshot(), 1, 2, 3, 4, 5)
+
+	data, _ := q.GobEncode()
+	if err := (&IndexedPriorityQueue[int]{}).GobDecode(data); err == nil {
+		t.Fatal("decoding into a queue without a comparator should fail")
+	}
+	decoded := NewIndexedPriorityQueue(intLess)
+	old := decoded.Push(0)
+	if err := decoded.GobDecode(data); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Contains(old) {
+		t.Fatal("a handle from before decoding is still in the queue")
+	}
+	// 通过 Handles 重新取得句柄后可以更新元素
+	for _, h := range decoded.Handles() {
+		if h.Value == 5 {
+			decoded.Update(h, 0)
+		}
+	}
+	expectInts(t, decoded.Snapshot(), 0, 1, 2, 3, 4)
+}
+
+func TestBlockingPriorityQueueSnapshotRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	q := NewBlockingPriorityQueue(intLess)
+	for _, v := range []int{3, 1, 2} {
+		_ = q.Put(ctx, v)
+	}
+	data, _ := json.Marshal(q)
+
+	restored := NewBlockingPriorityQueue(intLess)
+	result := make(chan int, 1)
+	go func() {
+		v, _ := restored.Take(ctx)
+		result <- v
+	}()
+	if err := json.Unmarshal(data, restored); err != nil {
+		t.Fatal(err)
+	}
+	// 恢复会唤醒等待中的消费者
+	if v := <-result; v != 1 {
+		t.Fatalf("Take after decoding: got %d, want 1", v)
+	}
+	expectInts(t, restored.Snapshot(), 2, 3)
+}
+
+func TestDelayQueueSnapshotRoundTrip(t *testing.T) {
+	clock := newFakeClock()
+	q := NewDelayQueueWithClock[int](clock)
+	_ = q.Put("a", 1, 2*time.Second)
+	_ = q.Put("", 2, time.Second)
+	_ = q.Put("c", 3, 3*time.Second)
+	expectInts(t, q.Snapshot(), 2, 1, 3)
+
+	data, _ := json.Marshal(q)
+	if err := json.Unmarshal(data, &DelayQueue[int]{}); err == nil {
+		t.Fatal("decoding into a queue not created by NewDelayQueue should fail")
+	}
+	restored := NewDelayQueueWithClock[int](clock)
+	if err := json.Unmarshal(data, restored); err != nil {
+		t.Fatal(err)
+	}
+	// 到期时间和 key 都被保留
+	if due, ok := restored.Due("a"); !ok || !due.Equal(clock.Now().Add(2*time.Second)) {
+		t.Fatalf("Due(a): got %v %v", due, ok)
+	}
+	if !restored.Cancel("c") {
+		t.Fatal("the key of c was not restored")
+	}
+	if _, err := restored.TryTake(); err != ErrEmpty {
+		t.Fatalf("TryTake before the due time: got %v, want ErrEmpty", err)
+	}
+	clock.Advance(2 * time.Second)
+	expectInts(t, restored.Drain(), 2, 1)
+}
+
+func TestFairQueueSnapshotRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	opts := FairQueueOptions[string, int]{PartitionCapacity: 2}
+	q := NewFairQueue(opts)
+	_ = q.TryPutKey("a", 1)
+	_ = q.TryPutKey("b", 2)
+	_ = q.TryPutKey("a", 3)
+	_ = q.TryPutKey("b", 4)
+	_, _ = q.Take(ctx) // 下一个被调度的分区是 b
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(q); err != nil {
+		t.Fatal(err)
+	}
+	restored := NewFairQueue(opts)
+	if err := gob.NewDecoder(&buf).Decode(restored); err != nil {
+		t.Fatal(err)
+	}
+	if restored.Len() != 3 || restored.PartitionLen("b") != 2 {
+		t.Fatalf("Len %d, PartitionLen(b) %d", restored.Len(), restored.PartitionLen("b"))
+	}
+	var got []int
+	for restored.Len() > 0 {
+		v, _ := restored.Take(ctx)
+		got = append(got, v)
+	}
+	expectInts(t, got, 2, 3, 4)
+
+	data, _ := json.Marshal(q)
+	small := NewFairQueue(FairQueueOptions[string, int]{PartitionCapacity: 1})
+	if err := json.Unmarshal(data, small); err == nil {
+		t.Fatal("decoding more items than the partition capacity should fail")
+	}
+	if err := json.Unmarshal(data, &FairQueue[string, int]{}); err == nil {
+		t.Fatal("decoding into a queue not created by NewFairQueue should fail")
+	}
+}
+
+func TestBlockingQueueSnapshotRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	q := NewBlockingQueue[int](3)
+	_ = q.Put(ctx, 1)
+	_ = q.Put(ctx, 2)
+
+	data, _ := q.GobEncode()
+	hook := &MetricsHook[int]{}
+	restored := NewBlockingQueueWithHook[int](0, hook)
+	if err := restored.GobDecode(data); err != nil {
+		t.Fatal(err)
+	}
+	if restored.Cap() != 3 {
+		t.Fatalf("Cap: got %d, want 3", restored.Cap())
+	}
+	expectInts(t, restored.Snapshot(), 1, 2)
+	if v, _ := restored.Take(ctx); v != 1 || hook.Metrics().Dequeued != 1 {
+		t.Fatal("the hook should be kept after decoding")
+	}
+}
+
+func TestBlockingQueueRestoreRace(t *testing.T) {
+	q := NewBlockingQueue[int](2)
+	data, _ := json.Marshal(q)
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			_ = q.UnmarshalJSON(data)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			_ = q.Cap()
+		}
+	}()
+	wg.Wait()
+}
+
+func TestMetricsHook(t *testing.T) {
+	ctx := context.Background()
+	hook := &MetricsHook[int]{}
+	q := NewBlockingQueueWithHook[int](2, hook)
+	_ = q.Put(ctx, 1)
+	_ = q.Put(ctx, 2)
+	if err := q.Offer(3, time.Millisecond); err != ErrFull {
+		t.Fatalf("Offer: got %v, want ErrFull", err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	_, _ = q.TakeN(ctx, 2)
+	q.Close()
+	_ = q.Put(ctx, 4)
+
+	m := hook.Metrics()
+	if m.Enqueued != 2 || m.Dequeued != 2 || m.Dropped != 2 || m.Depth != 0 {
+		t.Fatalf("unexpected metrics %+v", m)
+	}
+	if m.MaxWait < 10*time.Millisecond || m.AvgWait < 10*time.Millisecond {
+		t.Fatalf("wait times too short: %+v", m)
+	}
+}
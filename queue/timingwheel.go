@@ -0,0 +1,219 @@
+package queue
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// 分层时间轮，大量定时任务共享一个 ticker，添加和取消任务的复杂度均为 O(1)
+// 第 0 层每个槽位代表一个 tick，第 n 层每个槽位代表 wheelSize^n 个 tick，
+// 超出当前层范围的任务放入上一层（溢出轮），随着时间推进逐层下降到第 0 层后执行
+
+type TimingWheel struct {
+	tick      time.Duration
+	wheelSize int64
+
+	mutex   sync.Mutex
+	levels  [][]LinkedList[*WheelTimer] // levels[n][slot]，按需创建溢出轮
+	current int64                       // 已经走过的 tick 数
+	start   time.Time
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// WheelTimer 时间轮中的定时任务
+type WheelTimer struct {
+	wheel  *TimingWheel
+	fn     func()
+	expire int64 // 到期时的 tick 数
+	node   *LinkedNode[*WheelTimer]
+	slot   *LinkedList[*WheelTimer]
+}
+
+// NewTimingWheel returns a timing wheel with the given tick precision and slots per level,
+// the wheel must be started with Start before timers fire
+func NewTimingWheel(tick time.Duration, wheelSize int) *TimingWheel {
+	if tick <= 0 {
+		panic("queue: non-positive tick for NewTimingWheel")
+	}
+	if wheelSize < 2 {
+		panic("queue: wheel size must be at least 2")
+	}
+	tw := &TimingWheel{
+		tick:      tick,
+		wheelSize: int64(wheelSize),
+	}
+	tw.levels = append(tw.levels, make([]LinkedList[*WheelTimer], wheelSize))
+	return tw
+}
+
+// Start starts the goroutine advancing the wheel
+func (tw *TimingWheel) Start() {
+	tw.mutex.Lock()
+	defer tw.mutex.Unlock()
+	if tw.stop != nil {
+		return
+	}
+	tw.stop = make(chan struct{})
+	tw.done = make(chan struct{})
+	tw.start = time.Now().Add(-time.Duration(tw.current) * tw.tick)
+	go tw.run(tw.stop, tw.done)
+}
+
+// Stop stops advancing the wheel, pending timers are kept and fire after a new Start
+func (tw *TimingWheel) Stop() {
+	tw.mutex.Lock()
+	stop, done := tw.stop, tw.done
+	tw.stop, tw.done = nil, nil
+	tw.mutex.Unlock()
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+}
+
+func (tw *TimingWheel) run(stop, done chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(tw.tick)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			// 根据实际经过的时间推进，避免 ticker 丢失 tick 带来的误差累积
+			tw.advanceTo(int64(now.Sub(tw.start) / tw.tick))
+		case <-stop:
+			return
+		}
+	}
+}
+
+// AfterFunc waits for at least d and then calls fn in its own goroutine
+func (tw *TimingWheel) AfterFunc(d time.Duration, fn func()) *WheelTimer {
+	t := &WheelTimer{wheel: tw, fn: fn}
+	tw.mutex.Lock()
+	due := tw.add(t, tw.expireAfter(d))
+	tw.mutex.Unlock()
+	if due {
+		go fn()
+	}
+	return t
+}
+
+// Stop cancels the timer in O(1), it returns false if the timer already fired or was stopped
+func (t *WheelTimer) Stop() bool {
+	t.wheel.mutex.Lock()
+	defer t.wheel.mutex.Unlock()
+	return t.remove()
+}
+
+// Reset cancels the timer and schedules it again after d,
+// it returns whether the timer was still pending
+func (t *WheelTimer) Reset(d time.Duration) bool {
+	tw := t.wheel
+	tw.mutex.Lock()
+	pending := t.remove()
+	due := tw.add(t, tw.expireAfter(d))
+	tw.mutex.Unlock()
+	if due {
+		go t.fn()
+	}
+	return pending
+}
+
+func (t *WheelTimer) remove() bool {
+	if t.slot == nil {
+		return false
+	}
+	t.slot.Remove(t.node)
+	t.slot, t.node = nil, nil
+	return true
+}
+
+// Len returns the number of pending timers
+func (tw *TimingWheel) Len() int {
+	tw.mutex.Lock()
+	defer tw.mutex.Unlock()
+	n := 0
+	for _, level := range tw.levels {
+		for i := range level {
+			n += level[i].Len()
+		}
+	}
+	return n
+}
+
+// expireAfter 返回 d 之后到期的 tick 数，d 向上取整且至少为 1 个 tick，溢出时取最大值，调用方需持有锁
+func (tw *TimingWheel) expireAfter(d time.Duration) int64 {
+	n := int64(d / tw.tick)
+	if d%tw.tick > 0 {
+		n++
+	}
+	if n < 1 {
+		n = 1
+	}
+	if n > math.MaxInt64-tw.current {
+		return math.MaxInt64
+	}
+	return tw.current + n
+}
+
+// add 将任务放入对应的槽位，已经到期时返回 true 由调用方执行，调用方需持有锁
+func (tw *TimingWheel) add(t *WheelTimer, expire int64) bool {
+	t.expire = expire
+	if expire <= tw.current {
+		return true
+	}
+
+	interval := int64(1)
+	for level := 0; ; level++ {
+		if level == len(tw.levels) {
+			tw.levels = append(tw.levels, make([]LinkedList[*WheelTimer], tw.wheelSize))
+		}
+		// 到期时间与当前时间在本层相差不足一圈时放入本层
+		if expire/interval-tw.current/interval < tw.wheelSize {
+			t.slot = &tw.levels[level][(expire/interval)%tw.wheelSize]
+			t.node = t.slot.PushBack(t)
+			return false
+		}
+		interval *= tw.wheelSize
+	}
+}
+
+// advanceTo 推进到第 target 个 tick，执行所有到期的任务
+func (tw *TimingWheel) advanceTo(target int64) {
+	var due []func()
+	tw.mutex.Lock()
+	for tw.current < target {
+		tw.current++
+
+		// 先将上层到期槽位中的任务降级，再执行第 0 层的槽位
+		interval := int64(1)
+		for level := 1; level < len(tw.levels); level++ {
+			interval *= tw.wheelSize
+			if tw.current%interval != 0 {
+				break
+			}
+			slot := &tw.levels[level][(tw.current/interval)%tw.wheelSize]
+			for slot.Len() > 0 {
+				t, _ := slot.PopFront()
+				t.slot, t.node = nil, nil
+				if tw.add(t, t.expire) {
+					due = append(due, t.fn)
+				}
+			}
+		}
+
+		slot := &tw.levels[0][tw.current%tw.wheelSize]
+		for slot.Len() > 0 {
+			t, _ := slot.PopFront()
+			t.slot, t.node = nil, nil
+			due = append(due, t.fn)
+		}
+	}
+	tw.mutex.Unlock()
+
+	for _, fn := range due {
+		go fn()
+	}
+}
@@ -0,0 +1,115 @@
+package queue
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// advance 逐个 tick 推进到 target，并等待每个 tick 到期的任务执行
+// advanceTo 在协程中执行任务，任务通过 fired 报告自己的编号
+func advance(t *testing.T, tw *TimingWheel, fired chan int, expected map[int64][]int, target int64) {
+	t.Helper()
+	for tick := tw.current + 1; tick <= target; tick++ {
+		tw.advanceTo(tick)
+		want := make(map[int]bool)
+		for _, id := range expected[tick] {
+			want[id] = true
+		}
+		for range expected[tick] {
+			select {
+			case id := <-fired:
+				if !want[id] {
+					t.Fatalf("timer %d fired at tick %d", id, tick)
+				}
+				delete(want, id)
+			case <-time.After(5 * time.Second):
+				t.Fatalf("timers %v did not fire at tick %d", want, tick)
+			}
+		}
+	}
+}
+
+func TestTimingWheelOverflowLevels(t *testing.T) {
+	tw := NewTimingWheel(time.Millisecond, 4)
+	fired := make(chan int, 16)
+	expected := make(map[int64][]int)
+	// 跨越第 0、1、2、3 层
+	delays := []int64{1, 3, 4, 7, 16, 17, 63, 64, 200}
+	for i, d := range delays {
+		i := i
+		tw.AfterFunc(time.Duration(d)*time.Millisecond, func() { fired <- i })
+		expected[d] = append(expected[d], i)
+	}
+	if tw.Len() != len(delays) {
+		t.Fatalf("Len: got %d, want %d", tw.Len(), len(delays))
+	}
+
+	advance(t, tw, fired, expected, 250)
+	if tw.Len() != 0 {
+		t.Fatalf("Len after firing: got %d, want 0", tw.Len())
+	}
+	select {
+	case id := <-fired:
+		t.Fatalf("timer %d fired twice", id)
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+func TestTimingWheelHugeDelay(t *testing.T) {
+	tw := NewTimingWheel(time.Millisecond, 4)
+	fired := make(chan int, 2)
+	huge := tw.AfterFunc(math.MaxInt64, func() { fired <- 0 })
+	tw.AfterFunc(math.MaxInt64-time.Microsecond, func() { fired <- 1 })
+	// 溢出的到期时间取最大值，不会在下一个 tick 执行
+	advance(t, tw, fired, nil, 1000)
+	if tw.Len() != 2 {
+		t.Fatalf("Len: got %d, want 2", tw.Len())
+	}
+	if !huge.Reset(3 * time.Millisecond) {
+		t.Fatal("Reset of a pending timer returned false")
+	}
+	advance(t, tw, fired, map[int64][]int{1003: {0}}, 1003)
+}
+
+func TestTimingWheelStopAndReset(t *testing.T) {
+	tw := NewTimingWheel(time.Millisecond, 8)
+	fired := make(chan int, 2)
+
+	stopped := tw.AfterFunc(5*time.Millisecond, func() { fired <- 0 })
+	if !stopped.Stop() || stopped.Stop() {
+		t.Fatal("Stop should succeed exactly once")
+	}
+
+	reset := tw.AfterFunc(5*time.Millisecond, func() { fired <- 1 })
+	if !reset.Reset(20 * time.Millisecond) {
+		t.Fatal("Reset of a pending timer should report it as pending")
+	}
+	advance(t, tw, fired, map[int64][]int{20: {1}}, 100)
+	if reset.Stop() {
+		t.Fatal("Stop after firing should return false")
+	}
+	select {
+	case id := <-fired:
+		t.Fatalf("timer %d fired unexpectedly", id)
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+func TestTimingWheelRealTime(t *testing.T) {
+	tw := NewTimingWheel(time.Millisecond, 16)
+	tw.Start()
+	defer tw.Stop()
+
+	start := time.Now()
+	fired := make(chan time.Duration, 1)
+	tw.AfterFunc(30*time.Millisecond, func() { fired <- time.Since(start) })
+	select {
+	case d := <-fired:
+		if d < 30*time.Millisecond {
+			t.Fatalf("timer fired after %v, before its delay", d)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timer did not fire")
+	}
+}
@@ -0,0 +1,128 @@
+package snowflake
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrGeneratorClosed BufferedGenerator 已关闭
+var ErrGeneratorClosed = errors.New("snowflake: generator is closed")
+
+type bufferedID struct {
+	id  int64
+	err error
+}
+
+// defaultMaxBufferedAge 缓冲区中的 ID 默认的最长保留时间
+const defaultMaxBufferedAge = 100 * time.Millisecond
+
+// BufferedGenerator 在后台批量预取 ID 到缓冲区，NextID 只需要从 channel 中读取
+// 缓冲区中的 ID 在预取时生成，因此其时间戳可能早于取出的时间，超过 maxAge 的 ID 会被丢弃
+type BufferedGenerator struct {
+	gen    *IDGenerator
+	maxAge int64 // 毫秒
+	ids    chan bufferedID
+	done   chan struct{}
+	once   sync.Once
+	wg     sync.WaitGroup
+}
+
+// NewBufferedGenerator returns a generator keeping up to size ids from gen ready,
+// ids whose timestamp is more than 100ms old are discarded instead of returned
+func NewBufferedGenerator(gen *IDGenerator, size int) *BufferedGenerator {
+	return NewBufferedGeneratorWithMaxAge(gen, size, defaultMaxBufferedAge)
+}
+
+// NewBufferedGeneratorWithMaxAge is like NewBufferedGenerator but discards the ids older than maxAge,
+// so that the timestamp of a returned id is at most maxAge before the call to NextID.
+// maxAge is rounded down to milliseconds, with 0 only ids of the current millisecond are returned
+func NewBufferedGeneratorWithMaxAge(gen *IDGenerator, size int, maxAge time.Duration) *BufferedGenerator {
+	if size < 1 {
+		size = 1
+	}
+	if maxAge < 0 {
+		maxAge = 0
+	}
+	b := &BufferedGenerator{
+		gen:    gen,
+		maxAge: maxAge.Milliseconds(),
+		ids:    make(chan bufferedID, size),
+		done:   make(chan struct{}),
+	}
+	b.wg.Add(1)
+	go b.fill(size)
+	return b
+}
+
+func (b *BufferedGenerator) fill(size int) {
+	defer b.wg.Done()
+	defer close(b.ids)
+
+	n := size
+	if n > maxNextIdsNum {
+		n = maxNextIdsNum
+	}
+	for {
+		ids, err := b.gen.NextIDs(n)
+		if err != nil {
+			// 把错误交给下一个调用方，稍后重试
+			select {
+			case b.ids <- bufferedID{err: err}:
+			case <-b.done:
+				return
+			}
+			select {
+			case <-time.After(time.Millisecond):
+			case <-b.done:
+				return
+			}
+			continue
+		}
+		for _, id := range ids {
+			select {
+			case b.ids <- bufferedID{id: id}:
+			case <-b.done:
+				return
+			}
+		}
+	}
+}
+
+// NextID returns the next buffered id, waiting for one if the buffer is empty
+func (b *BufferedGenerator) NextID() (int64, error) {
+	for {
+		select {
+		case <-b.done:
+			return 0, ErrGeneratorClosed
+		default:
+		}
+		v, ok := <-b.ids
+		if !ok {
+			return 0, ErrGeneratorClosed
+		}
+		if v.err != nil || !b.stale(v.id) {
+			return v.id, v.err
+		}
+		// 过期的 ID 直接丢弃，后台协程会补充新的 ID
+	}
+}
+
+// stale 判断 id 的时间戳是否早于当前时间 maxAge 以上
+func (b *BufferedGenerator) stale(id int64) bool {
+	layout := b.gen.layout
+	millis := id>>layout.timestampShift() + b.gen.startTimestamp
+	return b.gen.timeGen()-millis > b.maxAge
+}
+
+// Close stops prefetching and discards the ids left in the buffer,
+// NextID returns ErrGeneratorClosed afterwards
+func (b *BufferedGenerator) Close() {
+	b.once.Do(func() {
+		close(b.done)
+	})
+	b.wg.Wait()
+	// fill 退出时已关闭 channel，清空剩余的 ID
+	for range b.ids {
+	}
+}
@@ -0,0 +1,91 @@
+package snowflake
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextIDsSpillIntoNextMillisecond(t *testing.T) {
+	clock := newFakeClock()
+	layout := Layout{TimestampBits: 41, NodeBits: 4, SequenceBits: 5} // 每毫秒 32 个序号
+	gen, err := New(Options{Layout: layout, Clock: clock})
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := clock.Now()
+
+	var ids []int64
+	for len(ids) < 3*maxNextIdsNum {
+		batch, err := gen.NextIDs(maxNextIdsNum)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, batch...)
+	}
+	for i := 1; i < len(ids); i++ {
+		if ids[i] <= ids[i-1] {
+			t.Fatalf("id %d at %d is not greater than %d", ids[i], i, ids[i-1])
+		}
+	}
+	// 序号用完后进入下一毫秒继续生成
+	last, _ := gen.Parse(ids[len(ids)-1])
+	if want := start.Add(time.Duration((len(ids)-1)/32) * time.Millisecond); !last.Time.Equal(want) {
+		t.Fatalf("last id generated at %v, want %v", last.Time, want)
+	}
+
+	for _, n := range []int{0, -1, maxNextIdsNum + 1} {
+		if _, err := gen.NextIDs(n); err == nil {
+			t.Fatalf("NextIDs(%d) succeeded", n)
+		}
+	}
+}
+
+func TestBufferedGeneratorClose(t *testing.T) {
+	gen, _ := New(Options{NodeId: 1})
+	b := NewBufferedGenerator(gen, 16)
+	seen := make(map[int64]bool)
+	for i := 0; i < 100; i++ {
+		v, err := b.NextID()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if seen[v] {
+			t.Fatalf("duplicate id %d", v)
+		}
+		seen[v] = true
+	}
+
+	b.Close()
+	b.Close()
+	for i := 0; i < 20; i++ {
+		if v, err := b.NextID(); err != ErrGeneratorClosed {
+			t.Fatalf("NextID after Close: got %d %v, want ErrGeneratorClosed", v, err)
+		}
+	}
+	if len(b.ids) != 0 {
+		t.Fatalf("%d ids left in the buffer after Close", len(b.ids))
+	}
+}
+
+func TestBufferedGeneratorDiscardsStaleIds(t *testing.T) {
+	clock := newFakeClock()
+	gen, _ := New(Options{Clock: clock})
+	b := NewBufferedGeneratorWithMaxAge(gen, 16, 10*time.Millisecond)
+	defer b.Close()
+	if _, err := b.NextID(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 缓冲区中已有的 ID 都是一小时前生成的
+	clock.Advance(time.Hour)
+	for i := 0; i < 100; i++ {
+		v, err := b.NextID()
+		if err != nil {
+			t.Fatal(err)
+		}
+		id, _ := gen.Parse(v)
+		if age := clock.Now().Sub(id.Time); age > 10*time.Millisecond {
+			t.Fatalf("NextID returned an id %v old", age)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package snowflake
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrClockMovedBackwards 时钟回拨，无法生成 ID
+var ErrClockMovedBackwards = errors.New("snowflake: clock moved backwards")
+
+// Clock 时间源，测试时可以替换为可控的实现
+type Clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+}
+
+// SystemClock 基于 time 包的系统时钟
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+// SkewStrategy 时钟回拨时的处理策略
+type SkewStrategy int
+
+const (
+	SkewFail          SkewStrategy = iota // 返回 ErrClockMovedBackwards
+	SkewWait                              // 回拨不超过 Options.MaxSkewWait 时睡眠等待时钟追上，否则返回 ErrClockMovedBackwards
+	SkewLogical                           // 继续使用上一次的时间戳作为逻辑时钟，序号用完后逻辑时间加 1 毫秒
+	SkewRegressionBit                     // 时钟回拨位加 1 后使用当前时间继续生成，需要 Layout.RegressionBits 大于 0，回拨位用完后返回 ErrClockMovedBackwards
+)
@@ -0,0 +1,252 @@
+package snowflake
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock 手动控制的时钟，可以向前或向后调整，Sleep 直接推进时间
+type fakeClock struct {
+	mutex sync.Mutex
+	now   time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Sleep(d time.Duration) {
+	if d <= 0 {
+		// 与 time.Sleep 不同，至少推进 1 毫秒，避免等待下一毫秒时死循环
+		d = time.Millisecond
+	}
+	c.Advance(d)
+}
+
+// Advance moves the clock by d, a negative d moves it backwards
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.now = c.now.Add(d)
+}
+
+var regressionLayout = Layout{
+	Epoch:          DefaultLayout.Epoch,
+	TimestampBits:  42,
+	DistrictBits:   2,
+	RegressionBits: 2,
+	NodeBits:       7,
+	SequenceBits:   10,
+}
+
+func TestSkewStrategiesNoDuplicates(t *testing.T) {
+	tests := []struct {
+		name       string
+		opts       Options
+		increasing bool // 回拨后生成的 ID 是否仍然递增
+	}{
+		{"wait", Options{Skew: SkewWait, MaxSkewWait: 10 * time.Millisecond}, true},
+		{"logical", Options{Skew: SkewLogical}, true},
+		{"regression bit", Options{Skew: SkewRegressionBit, Layout: regressionLayout}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := newFakeClock()
+			tt.opts.Clock = clock
+			tt.opts.NodeId = 1
+			gen, err := New(tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			seen := make(map[int64]bool)
+			var last int64
+			generate := func(n int) {
+				t.Helper()
+				for i := 0; i < n; i++ {
+					v, err := gen.NextID()
+					if err != nil {
+						t.Fatal(err)
+					}
+					if seen[v] {
+						t.Fatalf("duplicate id %d", v)
+					}
+					if tt.increasing && v <= last {
+						t.Fatalf("id %d is not greater than %d", v, last)
+					}
+					seen[v] = true
+					last = v
+				}
+			}
+
+			// 每一步生成的 ID 数超过单毫秒的序号上限，覆盖序号用尽的情况
+			for step := 0; step < 5; step++ {
+				generate(1500)
+				clock.Advance(time.Millisecond)
+			}
+			clock.Advance(-5 * time.Millisecond)
+			generate(3000)
+			clock.Advance(-2 * time.Millisecond)
+			for step := 0; step < 10; step++ {
+				generate(100)
+				clock.Advance(time.Millisecond)
+			}
+		})
+	}
+}
+
+func TestSkewFail(t *testing.T) {
+	clock := newFakeClock()
+	gen, _ := New(Options{Clock: clock})
+	if _, err := gen.NextID(); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(-time.Millisecond)
+	if _, err := gen.NextID(); !errors.Is(err, ErrClockMovedBackwards) {
+		t.Fatalf("NextID: got %v, want ErrClockMovedBackwards", err)
+	}
+	clock.Advance(time.Millisecond)
+	if _, err := gen.NextID(); err != nil {
+		t.Fatalf("NextID after the clock caught up: %v", err)
+	}
+}
+
+func TestSkewWaitLimit(t *testing.T) {
+	tests := []struct {
+		backwards time.Duration
+		err       error
+	}{
+		{5 * time.Millisecond, nil},
+		{10 * time.Millisecond, nil},
+		{11 * time.Millisecond, ErrClockMovedBackwards},
+		{time.Second, ErrClockMovedBackwards},
+	}
+	for _, tt := range tests {
+		clock := newFakeClock()
+		gen, _ := New(Options{Clock: clock, Skew: SkewWait, MaxSkewWait: 10 * time.Millisecond})
+		first, _ := gen.NextID()
+		clock.Advance(-tt.backwards)
+		before := clock.Now()
+
+		v, err := gen.NextID()
+		if !errors.Is(err, tt.err) {
+			t.Fatalf("%v backwards: got %v, want %v", tt.backwards, err, tt.err)
+		}
+		if err != nil {
+			// 拒绝时不应等待
+			if !clock.Now().Equal(before) {
+				t.Fatalf("%v backwards: slept %v before failing", tt.backwards, clock.Now().Sub(before))
+			}
+			continue
+		}
+		if v <= first {
+			t.Fatalf("%v backwards: id %d is not greater than %d", tt.backwards, v, first)
+		}
+		if waited := clock.Now().Sub(before); waited < tt.backwards {
+			t.Fatalf("%v backwards: only waited %v", tt.backwards, waited)
+		}
+	}
+}
+
+func TestSkewRegressionBit(t *testing.T) {
+	clock := newFakeClock()
+	gen, err := New(Options{Clock: clock, Skew: SkewRegressionBit, Layout: regressionLayout})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	regression := func() int64 {
+		t.Helper()
+		v, err := gen.NextID()
+		if err != nil {
+			t.Fatal(err)
+		}
+		parsed, _ := gen.Parse(v)
+		return parsed.Regression
+	}
+
+	if r := regression(); r != 0 {
+		t.Fatalf("Regression: got %d, want 0", r)
+	}
+	// 每次回拨加 1
+	for _, want := range []int64{1, 2, 3} {
+		clock.Advance(-time.Millisecond)
+		if r := regression(); r != want {
+			t.Fatalf("Regression after a rollback: got %d, want %d", r, want)
+		}
+	}
+	// 回拨位用完后不回绕，避免与回拨位为 0 的 ID 重复
+	clock.Advance(-time.Millisecond)
+	if _, err := gen.NextID(); !errors.Is(err, ErrClockMovedBackwards) {
+		t.Fatalf("NextID after the regression values are used: got %v, want ErrClockMovedBackwards", err)
+	}
+	// 时钟追上后继续生成，回拨位保持不变
+	clock.Advance(time.Millisecond)
+	if r := regression(); r != 3 {
+		t.Fatalf("Regression without a rollback: got %d, want 3", r)
+	}
+
+	if _, err := New(Options{Skew: SkewRegressionBit}); !errors.Is(err, ErrInvalidLayout) {
+		t.Fatalf("SkewRegressionBit without RegressionBits: got %v, want ErrInvalidLayout", err)
+	}
+}
+
+// jumpClock 第一次 Sleep 之后把时钟回拨 back，并记录睡眠的总时间
+type jumpClock struct {
+	*fakeClock
+	back  time.Duration
+	slept time.Duration
+}
+
+func (c *jumpClock) Sleep(d time.Duration) {
+	c.fakeClock.Sleep(d)
+	c.slept += d
+	c.Advance(-c.back)
+	c.back = 0
+}
+
+func TestSkewWhileSequenceExhausted(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		err  error
+	}{
+		{"fail", Options{Skew: SkewFail}, ErrClockMovedBackwards},
+		{"wait beyond the limit", Options{Skew: SkewWait, MaxSkewWait: 10 * time.Millisecond}, ErrClockMovedBackwards},
+		{"wait within the limit", Options{Skew: SkewWait, MaxSkewWait: time.Hour}, nil},
+		{"regression bit", Options{Skew: SkewRegressionBit, Layout: regressionLayout}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &jumpClock{fakeClock: newFakeClock()}
+			tt.opts.Clock = clock
+			gen, err := New(tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// 用完当前毫秒的序号，下一次 NextID 需要等待下一毫秒，等待期间时钟回拨 1 小时
+			for i := int64(0); i <= gen.Layout().MaxSequence(); i++ {
+				if _, err = gen.NextID(); err != nil {
+					t.Fatal(err)
+				}
+			}
+			clock.back = time.Hour
+			if _, err = gen.NextID(); !errors.Is(err, tt.err) {
+				t.Fatalf("NextID: got %v, want %v", err, tt.err)
+			}
+			if tt.opts.Skew != SkewWait || tt.err != nil {
+				if clock.slept > time.Second {
+					t.Fatalf("slept %v while the sequence was exhausted", clock.slept)
+				}
+			}
+		})
+	}
+}
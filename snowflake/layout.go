@@ -0,0 +1,144 @@
+package snowflake
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrInvalidLayout      = errors.New("snowflake: invalid layout")
+	ErrNodeIdOverflow     = errors.New("snowflake: node id out of range")
+	ErrDistrictIdOverflow = errors.New("snowflake: district id out of range")
+	ErrTimestampOverflow  = errors.New("snowflake: timestamp out of range")
+)
+
+// Layout ID 的位布局，从高位到低位依次为：1 位符号位 | 时间戳 | 区域 | 时钟回拨 | 节点 | 毫秒内自增序号
+// 各字段的位数之和不能超过 63
+type Layout struct {
+	Epoch          time.Time // 起始时间，时间戳字段保存与它相差的毫秒数
+	TimestampBits  uint
+	DistrictBits   uint
+	RegressionBits uint // 时钟回拨位，使用 SkewRegressionBit 时每次时钟回拨加 1，默认为 0
+	NodeBits       uint
+	SequenceBits   uint
+}
+
+// DefaultLayout 默认布局，44 位时间戳 | 2 位区域 | 7 位节点 | 10 位自增序号
+var DefaultLayout = Layout{
+	Epoch:         time.UnixMilli(startTimestamp),
+	TimestampBits: timestampBits,
+	DistrictBits:  districtIdBits,
+	NodeBits:      nodeIdBits,
+	SequenceBits:  sequenceBits,
+}
+
+// Validate checks that the layout has a timestamp and a sequence field and fits in 63 bits
+func (l Layout) Validate() error {
+	if l.TimestampBits == 0 || l.SequenceBits == 0 {
+		return fmt.Errorf("%w: timestamp and sequence bits must not be 0", ErrInvalidLayout)
+	}
+	if total := l.TimestampBits + l.DistrictBits + l.RegressionBits + l.NodeBits + l.SequenceBits; total > 63 {
+		return fmt.Errorf("%w: %d bits exceed 63", ErrInvalidLayout, total)
+	}
+	return nil
+}
+
+// mask 返回低 bits 位全为 1 的值
+func mask(bits uint) int64 {
+	return -1 ^ (-1 << bits)
+}
+
+// MaxTimestamp returns the largest timestamp in milliseconds since the epoch
+func (l Layout) MaxTimestamp() int64 {
+	return mask(l.TimestampBits)
+}
+
+func (l Layout) MaxDistrictId() int64 {
+	return mask(l.DistrictBits)
+}
+
+func (l Layout) MaxRegression() int64 {
+	return mask(l.RegressionBits)
+}
+
+func (l Layout) MaxNodeId() int64 {
+	return mask(l.NodeBits)
+}
+
+func (l Layout) MaxSequence() int64 {
+	return mask(l.SequenceBits)
+}
+
+func (l Layout) nodeShift() uint {
+	return l.SequenceBits
+}
+
+func (l Layout) regressionShift() uint {
+	return l.SequenceBits + l.NodeBits
+}
+
+func (l Layout) districtShift() uint {
+	return l.SequenceBits + l.NodeBits + l.RegressionBits
+}
+
+func (l Layout) timestampShift() uint {
+	return l.SequenceBits + l.NodeBits + l.RegressionBits + l.DistrictBits
+}
+
+// compose 按布局拼接各字段，调用方需保证各字段在范围内
+func (l Layout) compose(timestamp, districtId, regression, nodeId, sequence int64) int64 {
+	return timestamp<<l.timestampShift() | districtId<<l.districtShift() | regression<<l.regressionShift() |
+		nodeId<<l.nodeShift() | sequence
+}
+
+// ID 解析后的 ID 各字段
+type ID struct {
+	Time       time.Time // 生成 ID 的时间，精确到毫秒
+	DistrictId int64
+	Regression int64 // 时钟回拨位的值
+	NodeId     int64
+	Sequence   int64
+}
+
+// Parse splits id into its fields, negative ids are rejected
+func (l Layout) Parse(id int64) (ID, error) {
+	if id < 0 {
+		return ID{}, fmt.Errorf("snowflake: invalid id %d", id)
+	}
+	elapsed := id >> l.timestampShift()
+	if elapsed > l.MaxTimestamp() {
+		return ID{}, fmt.Errorf("%w: id %d does not fit the layout", ErrTimestampOverflow, id)
+	}
+	return ID{
+		Time:       time.UnixMilli(l.Epoch.UnixMilli() + elapsed),
+		DistrictId: id >> l.districtShift() & l.MaxDistrictId(),
+		Regression: id >> l.regressionShift() & l.MaxRegression(),
+		NodeId:     id >> l.nodeShift() & l.MaxNodeId(),
+		Sequence:   id & l.MaxSequence(),
+	}, nil
+}
+
+// elapsed 返回 t 与 Epoch 相差的毫秒数，并限制在时间戳字段的范围内
+func (l Layout) elapsed(t time.Time) int64 {
+	elapsed := t.UnixMilli() - l.Epoch.UnixMilli()
+	if elapsed < 0 {
+		return 0
+	}
+	if elapsed > l.MaxTimestamp() {
+		return l.MaxTimestamp()
+	}
+	return elapsed
+}
+
+// MinIDForTime returns the smallest id that can be generated in the millisecond of t,
+// times outside the range of the layout are clamped
+func (l Layout) MinIDForTime(t time.Time) int64 {
+	return l.compose(l.elapsed(t), 0, 0, 0, 0)
+}
+
+// MaxIDForTime returns the largest id that can be generated in the millisecond of t,
+// times outside the range of the layout are clamped
+func (l Layout) MaxIDForTime(t time.Time) int64 {
+	return l.compose(l.elapsed(t), l.MaxDistrictId(), l.MaxRegression(), l.MaxNodeId(), l.MaxSequence())
+}
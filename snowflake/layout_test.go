@@ -0,0 +1,131 @@
+package snowflake
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLayoutValidate(t *testing.T) {
+	tests := []struct {
+		layout Layout
+		valid  bool
+	}{
+		{DefaultLayout, true},
+		{regressionLayout, true},
+		{Layout{TimestampBits: 41, DistrictBits: 5, NodeBits: 5, SequenceBits: 12}, true},
+		{Layout{TimestampBits: 50, NodeBits: 5, SequenceBits: 8}, true},
+		{Layout{TimestampBits: 50, NodeBits: 5, SequenceBits: 9}, false},
+		{Layout{NodeBits: 10, SequenceBits: 12}, false},
+		{Layout{TimestampBits: 41, NodeBits: 10}, false},
+	}
+	for _, tt := range tests {
+		err := tt.layout.Validate()
+		if tt.valid && err != nil {
+			t.Fatalf("Validate(%+v): %v", tt.layout, err)
+		}
+		if !tt.valid && !errors.Is(err, ErrInvalidLayout) {
+			t.Fatalf("Validate(%+v): got %v, want ErrInvalidLayout", tt.layout, err)
+		}
+	}
+}
+
+func TestNewOptions(t *testing.T) {
+	layout := Layout{TimestampBits: 41, DistrictBits: 3, NodeBits: 4, SequenceBits: 12}
+	tests := []struct {
+		opts Options
+		err  error
+	}{
+		{Options{Layout: layout, DistrictId: 7, NodeId: 15}, nil},
+		{Options{Layout: layout, DistrictId: 8}, ErrDistrictIdOverflow},
+		{Options{Layout: layout, DistrictId: -1}, ErrDistrictIdOverflow},
+		{Options{Layout: layout, NodeId: 16}, ErrNodeIdOverflow},
+		{Options{Layout: Layout{TimestampBits: 60, SequenceBits: 10}}, ErrInvalidLayout},
+		// 零值布局使用 DefaultLayout
+		{Options{NodeId: DefaultLayout.MaxNodeId()}, nil},
+	}
+	for _, tt := range tests {
+		gen, err := New(tt.opts)
+		if !errors.Is(err, tt.err) {
+			t.Fatalf("New(%+v): got %v, want %v", tt.opts, err, tt.err)
+		}
+		if err != nil {
+			continue
+		}
+		if !gen.Layout().Epoch.Equal(DefaultLayout.Epoch) {
+			t.Fatalf("Epoch: got %v, want the default epoch", gen.Layout().Epoch)
+		}
+	}
+}
+
+func TestTimestampOverflow(t *testing.T) {
+	clock := newFakeClock()
+	// 8 位时间戳只能表示 255 毫秒
+	layout := Layout{Epoch: clock.Now(), TimestampBits: 8, SequenceBits: 4}
+	gen, err := New(Options{Layout: layout, Clock: clock})
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(255 * time.Millisecond)
+	if _, err = gen.NextID(); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(time.Millisecond)
+	if _, err = gen.NextID(); !errors.Is(err, ErrTimestampOverflow) {
+		t.Fatalf("NextID past the layout range: got %v, want ErrTimestampOverflow", err)
+	}
+
+	clock = newFakeClock()
+	layout.Epoch = clock.Now().Add(time.Second)
+	gen, _ = New(Options{Layout: layout, Clock: clock})
+	if _, err = gen.NextID(); !errors.Is(err, ErrTimestampOverflow) {
+		t.Fatalf("NextID before the epoch: got %v, want ErrTimestampOverflow", err)
+	}
+}
+
+func TestParseRoundTrip(t *testing.T) {
+	clock := newFakeClock()
+	layout := Layout{TimestampBits: 41, DistrictBits: 3, RegressionBits: 1, NodeBits: 4, SequenceBits: 12}
+	gen, err := New(Options{Layout: layout, DistrictId: 5, NodeId: 9, Clock: clock})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		v, err := gen.NextID()
+		if err != nil {
+			t.Fatal(err)
+		}
+		id, err := gen.Parse(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !id.Time.Equal(clock.Now()) || id.DistrictId != 5 || id.NodeId != 9 ||
+			id.Regression != 0 || id.Sequence != int64(i) {
+			t.Fatalf("Parse(%d): got %+v", v, id)
+		}
+		// 同一毫秒生成的 ID 都在 MinIDForTime 和 MaxIDForTime 之间
+		if v < gen.MinIDForTime(clock.Now()) || v > gen.MaxIDForTime(clock.Now()) {
+			t.Fatalf("id %d outside of [%d, %d]", v, gen.MinIDForTime(clock.Now()), gen.MaxIDForTime(clock.Now()))
+		}
+	}
+	// 相邻毫秒的范围首尾相接
+	next := clock.Now().Add(time.Millisecond)
+	if gen.MaxIDForTime(clock.Now())+1 != gen.MinIDForTime(next) {
+		t.Fatal("the id ranges of consecutive milliseconds are not contiguous")
+	}
+	if min, _ := gen.Parse(gen.MinIDForTime(next)); !min.Time.Equal(next) || min.Sequence != 0 {
+		t.Fatalf("Parse(MinIDForTime): got %+v", min)
+	}
+
+	if _, err = gen.Parse(-1); err == nil {
+		t.Fatal("Parse of a negative id succeeded")
+	}
+	// 超出布局范围的时间被截断
+	if gen.MinIDForTime(time.Time{}) != 0 {
+		t.Fatal("MinIDForTime before the epoch is not 0")
+	}
+	last := gen.Layout().Epoch.Add(time.Duration(layout.MaxTimestamp()) * time.Millisecond)
+	if max, _ := gen.Parse(gen.MaxIDForTime(time.Unix(1<<40, 0))); !max.Time.Equal(last) {
+		t.Fatalf("MaxIDForTime far in the future: got %v, want %v", max.Time, last)
+	}
+}
@@ -1,7 +1,6 @@
 package snowflake
 
 import (
-	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -14,62 +13,148 @@ const (
 	sequenceBits   = uint(10)             //自增ID 所占位数
 
 	/*
-	 * 1位 符号位  |  41位时间戳                                   | 2位区域位  |  3位时钟序列    |  7位节点       | 10 （毫秒内）自增ID
-	 * 0          | 0 00000000 00000000 00000000 00000000 00000000 | 00  	| 	000   	   |  0000000 		| 0000000000
+	 * 1位 符号位  |  44位时间戳                                          | 2位区域位 |  7位节点  | 10 （毫秒内）自增ID
+	 * 0          | 0000 00000000 00000000 00000000 00000000 00000000 | 00       |  0000000 | 0000000000
 	 *
 	 */
-	maxNodeId     = -1 ^ (-1 << nodeIdBits)     //节点 ID 最大范围
-	maxDistrictId = -1 ^ (-1 << districtIdBits) //最大区域范围
-
-	nodeIdShift        = sequenceBits //左移次数
-	districtIdShift    = sequenceBits + nodeIdBits
-	timestampLeftShift = sequenceBits + nodeIdBits + districtIdBits
-	sequenceMask       = -1 ^ (-1 << sequenceBits)
-	maxNextIdsNum      = 100 //单次获取ID的最大数量
+	timestampBits = 63 - districtIdBits - nodeIdBits - sequenceBits //时间戳 所占位数
+	maxNextIdsNum = 100                                             //单次获取ID的最大数量
 )
 
+const defaultMaxSkewWait = 10 * time.Millisecond
+
+// Options 创建 IDGenerator 的选项
+type Options struct {
+	Layout      Layout // 零值时使用 DefaultLayout，Epoch 为零值时使用 DefaultLayout.Epoch
+	DistrictId  int64
+	NodeId      int64
+	Clock       Clock         // 默认为 SystemClock
+	Skew        SkewStrategy  // 时钟回拨时的处理策略，默认为 SkewFail
+	MaxSkewWait time.Duration // SkewWait 最多等待的时间，默认 10ms
+}
+
 type IDGenerator struct {
 	sequence       int64 //序号
 	lastTimestamp  int64 //最后一次请求的时间戳
 	nodeId         int64 //节点ID
 	startTimestamp int64
 	districtId     int64
+	regression     int64 //时钟回拨位
+	layout         Layout
+	clock          Clock
+	skew           SkewStrategy
+	maxSkewWait    time.Duration
 	mutex          sync.Mutex
 }
 
-// NewIDGenerator return a snowflake id generator
+// NewIDGenerator return a snowflake id generator using DefaultLayout and district 1
 func NewIDGenerator(NodeId int64) (*IDGenerator, error) {
-	var districtId int64
-	districtId = 1 //暂时默认给1 ，方便以后扩展
-	generator := &IDGenerator{}
-	if NodeId > maxNodeId || NodeId < 0 {
-		return nil, errors.New(fmt.Sprintf("NodeId Id: %d error", NodeId))
+	return New(Options{
+		Layout:     DefaultLayout,
+		DistrictId: 1, //暂时默认给1 ，方便以后扩展
+		NodeId:     NodeId,
+	})
+}
+
+// New returns a snowflake id generator with the given layout, district and node
+func New(opts Options) (*IDGenerator, error) {
+	layout := opts.Layout
+	if layout.TimestampBits == 0 && layout.DistrictBits == 0 && layout.RegressionBits == 0 &&
+		layout.NodeBits == 0 && layout.SequenceBits == 0 {
+		layout.TimestampBits = DefaultLayout.TimestampBits
+		layout.DistrictBits = DefaultLayout.DistrictBits
+		layout.NodeBits = DefaultLayout.NodeBits
+		layout.SequenceBits = DefaultLayout.SequenceBits
+	}
+	if layout.Epoch.IsZero() {
+		layout.Epoch = DefaultLayout.Epoch
+	}
+	if err := layout.Validate(); err != nil {
+		return nil, err
+	}
+	if opts.NodeId > layout.MaxNodeId() || opts.NodeId < 0 {
+		return nil, fmt.Errorf("%w: %d not in [0, %d]", ErrNodeIdOverflow, opts.NodeId, layout.MaxNodeId())
+	}
+	if opts.DistrictId > layout.MaxDistrictId() || opts.DistrictId < 0 {
+		return nil, fmt.Errorf("%w: %d not in [0, %d]", ErrDistrictIdOverflow, opts.DistrictId, layout.MaxDistrictId())
 	}
-	if districtId > maxDistrictId || districtId < 0 {
-		return nil, errors.New(fmt.Sprintf("District Id: %d error", districtId))
+	if opts.Skew == SkewRegressionBit && layout.RegressionBits == 0 {
+		return nil, fmt.Errorf("%w: SkewRegressionBit requires RegressionBits", ErrInvalidLayout)
 	}
-	generator.nodeId = NodeId
-	generator.districtId = districtId
-	generator.lastTimestamp = -1
-	generator.sequence = 0
-	generator.startTimestamp = startTimestamp
-	generator.mutex = sync.Mutex{}
-
-	return generator, nil
+	if opts.Clock == nil {
+		opts.Clock = SystemClock
+	}
+	if opts.MaxSkewWait <= 0 {
+		opts.MaxSkewWait = defaultMaxSkewWait
+	}
+
+	return &IDGenerator{
+		nodeId:         opts.NodeId,
+		districtId:     opts.DistrictId,
+		lastTimestamp:  -1,
+		startTimestamp: layout.Epoch.UnixMilli(),
+		layout:         layout,
+		clock:          opts.Clock,
+		skew:           opts.Skew,
+		maxSkewWait:    opts.MaxSkewWait,
+	}, nil
+}
+
+// Layout returns the bit layout of the generated ids
+func (id *IDGenerator) Layout() Layout {
+	return id.layout
 }
 
 // timeGen generate a unix millisecond.
-func timeGen() int64 {
-	return time.Now().UnixNano() / int64(time.Millisecond)
+func (id *IDGenerator) timeGen() int64 {
+	return id.clock.Now().UnixMilli()
+}
+
+// tilNextMillis sleep till next millisecond.
+// 每次只睡眠到下一毫秒，期间发生的时钟回拨仍按 skew 策略处理
+func (id *IDGenerator) tilNextMillis(lastTimestamp int64) (int64, error) {
+	for {
+		regression := id.regression
+		timestamp, err := id.currentMillis()
+		if err != nil {
+			return 0, err
+		}
+		// 回拨位已经切换，回拨后的时间戳可以直接使用
+		if timestamp > lastTimestamp || id.regression != regression {
+			return timestamp, nil
+		}
+		id.clock.Sleep(time.UnixMilli(lastTimestamp + 1).Sub(id.clock.Now()))
+	}
 }
 
-// tilNextMillis spin wait till next millisecond.
-func tilNextMillis(lastTimestamp int64) int64 {
-	timestamp := timeGen()
-	for timestamp <= lastTimestamp {
-		timestamp = timeGen()
+// currentMillis 返回当前的毫秒时间戳，时钟回拨时按 skew 策略处理
+func (id *IDGenerator) currentMillis() (int64, error) {
+	timestamp := id.timeGen()
+	for timestamp < id.lastTimestamp {
+		backwards := time.Duration(id.lastTimestamp-timestamp) * time.Millisecond
+		switch id.skew {
+		case SkewWait:
+			if backwards > id.maxSkewWait {
+				return 0, fmt.Errorf("%w: refusing to generate id for %v", ErrClockMovedBackwards, backwards)
+			}
+			id.clock.Sleep(backwards)
+			timestamp = id.timeGen()
+		case SkewLogical:
+			return id.lastTimestamp, nil
+		case SkewRegressionBit:
+			// 切换到新的回拨位后，回拨区间内的时间戳不会与之前生成的 ID 重复；
+			// 回拨位不回绕，回绕后可能与使用同一回拨位的旧 ID 重复
+			if id.regression == id.layout.MaxRegression() {
+				return 0, fmt.Errorf("%w: all %d regression values are used, refusing to generate id for %v",
+					ErrClockMovedBackwards, id.layout.MaxRegression()+1, backwards)
+			}
+			id.regression++
+			return timestamp, nil
+		default:
+			return 0, fmt.Errorf("%w: refusing to generate id for %v", ErrClockMovedBackwards, backwards)
+		}
 	}
-	return timestamp
+	return timestamp, nil
 }
 
 // NextID get a snowflake id.
@@ -80,18 +165,63 @@ func (id *IDGenerator) NextID() (int64, error) {
 }
 
 func (id *IDGenerator) nextID() (int64, error) {
-	timestamp := timeGen()
-	if timestamp < id.lastTimestamp {
-		return 0, errors.New(fmt.Sprintf("Clock moved backwards.  Refusing to generate id for %d milliseconds", id.lastTimestamp-timestamp))
+	timestamp, err := id.currentMillis()
+	if err != nil {
+		return 0, err
 	}
 	if id.lastTimestamp == timestamp {
-		id.sequence = (id.sequence + 1) & sequenceMask
+		id.sequence = (id.sequence + 1) & id.layout.MaxSequence()
 		if id.sequence == 0 {
-			timestamp = tilNextMillis(id.lastTimestamp)
+			if id.skew == SkewLogical {
+				// 逻辑时钟不等待实际时间，直接借用下一毫秒
+				timestamp++
+			} else if timestamp, err = id.tilNextMillis(id.lastTimestamp); err != nil {
+				return 0, err
+			}
 		}
 	} else {
 		id.sequence = 0
 	}
+
+	elapsed := timestamp - id.startTimestamp
+	if elapsed < 0 || elapsed > id.layout.MaxTimestamp() {
+		return 0, fmt.Errorf("%w: %d milliseconds since the epoch", ErrTimestampOverflow, elapsed)
+	}
 	id.lastTimestamp = timestamp
-	return ((timestamp - id.startTimestamp) << timestampLeftShift) | (id.districtId << districtIdShift) | (id.nodeId << nodeIdShift) | id.sequence, nil
+	return id.layout.compose(elapsed, id.districtId, id.regression, id.nodeId, id.sequence), nil
+}
+
+// Parse splits an id generated by this generator into its fields
+func (id *IDGenerator) Parse(value int64) (ID, error) {
+	return id.layout.Parse(value)
+}
+
+// MinIDForTime returns the smallest id this generator's layout allows in the millisecond of t
+func (id *IDGenerator) MinIDForTime(t time.Time) int64 {
+	return id.layout.MinIDForTime(t)
+}
+
+// MaxIDForTime returns the largest id this generator's layout allows in the millisecond of t
+func (id *IDGenerator) MaxIDForTime(t time.Time) int64 {
+	return id.layout.MaxIDForTime(t)
+}
+
+// NextIDs reserves n consecutive ids under a single lock, 0 < n <= maxNextIdsNum.
+// When the sequence of the current millisecond is exhausted it continues in the next one
+func (id *IDGenerator) NextIDs(n int) ([]int64, error) {
+	if n <= 0 || n > maxNextIdsNum {
+		return nil, fmt.Errorf("snowflake: n must be in [1, %d], got %d", maxNextIdsNum, n)
+	}
+
+	id.mutex.Lock()
+	defer id.mutex.Unlock()
+	ids := make([]int64, n)
+	for i := range ids {
+		v, err := id.nextID()
+		if err != nil {
+			return nil, err
+		}
+		ids[i] = v
+	}
+	return ids, nil
 }
@@ -0,0 +1,151 @@
+package stream
+
+// 类型会发生变化的中间操作和终结操作，由于 Go 的方法不能声明类型参数，这里以函数形式提供
+
+// Map transforms every element of s with fn
+func Map[T, U any](s Stream[T], fn func(T) U) Stream[U] {
+	return derive(s, func() (u U, ok bool) {
+		v, ok := s.Next()
+		if !ok {
+			return
+		}
+		return fn(v), true
+	})
+}
+
+// FlatMap transforms every element of s into a slice and flattens the result
+func FlatMap[T, U any](s Stream[T], fn func(T) []U) Stream[U] {
+	var buf []U
+	return derive(s, func() (u U, ok bool) {
+		for len(buf) == 0 {
+			v, ok := s.Next()
+			if !ok {
+				return u, false
+			}
+			buf = fn(v)
+		}
+		u, buf = buf[0], buf[1:]
+		return u, true
+	})
+}
+
+// Concat yields the elements of every stream in order
+func Concat[T any](ss ...Stream[T]) Stream[T] {
+	return Stream[T]{
+		next: func() (v T, ok bool) {
+			for len(ss) > 0 {
+				if v, ok = ss[0].Next(); ok {
+					return
+				}
+				if ss[0].Err() != nil {
+					return
+				}
+				ss = ss[1:]
+			}
+			return
+		},
+		err: func() error {
+			if len(ss) > 0 {
+				return ss[0].Err()
+			}
+			return nil
+		},
+	}
+}
+
+// Chunk groups consecutive elements into slices of size n, the last chunk may be shorter.
+// n <= 0: the result is an empty stream
+func Chunk[T any](s Stream[T], n int) Stream[[]T] {
+	return derive(s, func() ([]T, bool) {
+		if n <= 0 {
+			return nil, false
+		}
+		chunk := make([]T, 0, n)
+		for len(chunk) < n {
+			v, ok := s.Next()
+			if !ok {
+				break
+			}
+			chunk = append(chunk, v)
+		}
+		return chunk, len(chunk) > 0
+	})
+}
+
+// Window yields sliding windows of size elements, advancing step elements each time.
+// Only full windows are yielded; size <= 0 or step <= 0 results in an empty stream
+func Window[T any](s Stream[T], size, step int) Stream[[]T] {
+	var buf []T
+	first := true
+	return derive(s, func() ([]T, bool) {
+		if size <= 0 || step <= 0 {
+			return nil, false
+		}
+		if !first {
+			if step < len(buf) {
+				buf = append(buf[:0:0], buf[step:]...)
+			} else {
+				// 步长大于窗口时，丢弃两个窗口之间的元素
+				for i := len(buf); i < step; i++ {
+					if _, ok := s.Next(); !ok {
+						return nil, false
+					}
+				}
+				buf = nil
+			}
+		}
+		first = false
+		for len(buf) < size {
+			v, ok := s.Next()
+			if !ok {
+				return nil, false
+			}
+			buf = append(buf, v)
+		}
+		return buf, true
+	})
+}
+
+// Distinct drops elements that have already been seen
+func Distinct[T comparable](s Stream[T]) Stream[T] {
+	return DistinctBy(s, func(v T) T { return v })
+}
+
+// DistinctBy drops elements whose key has already been seen
+func DistinctBy[T any, K comparable](s Stream[T], key func(T) K) Stream[T] {
+	seen := make(map[K]struct{})
+	return s.Filter(func(v T) bool {
+		k := key(v)
+		if _, ok := seen[k]; ok {
+			return false
+		}
+		seen[k] = struct{}{}
+		return true
+	})
+}
+
+// Reduce folds the stream into a single value starting from init
+func Reduce[T, U any](s Stream[T], init U, fn func(U, T) U) U {
+	acc := init
+	for v, ok := s.Next(); ok; v, ok = s.Next() {
+		acc = fn(acc, v)
+	}
+	return acc
+}
+
+// ToChannel consumes s in a new goroutine and sends the elements to the returned channel,
+// the channel is closed once the stream is exhausted or done is closed
+func ToChannel[T any](s Stream[T], done <-chan struct{}) <-chan T {
+	ch := make(chan T)
+	go func() {
+		defer close(ch)
+		for v, ok := s.Next(); ok; v, ok = s.Next() {
+			select {
+			case ch <- v:
+			case <-done:
+				return
+			}
+		}
+	}()
+	return ch
+}
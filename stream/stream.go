@@ -0,0 +1,225 @@
+package stream
+
+import (
+	"bufio"
+)
+
+// Stream 惰性求值的数据流，只有在终结操作（Collect、Reduce、ForEach 等）消费时才会逐个计算元素
+type Stream[T any] struct {
+	next func() (T, bool)
+	err  func() error
+}
+
+// New creates a stream from a pull function, next returns false when the stream is exhausted
+func New[T any](next func() (T, bool)) Stream[T] {
+	return Stream[T]{next: next}
+}
+
+// Empty returns a stream without any element
+func Empty[T any]() Stream[T] {
+	return New(func() (v T, ok bool) { return })
+}
+
+// Of creates a stream from the given values
+func Of[T any](vs ...T) Stream[T] {
+	return FromSlice(vs)
+}
+
+// FromSlice creates a stream over the elements of s, the slice is not copied
+func FromSlice[T any](s []T) Stream[T] {
+	i := 0
+	return New(func() (v T, ok bool) {
+		if i >= len(s) {
+			return
+		}
+		v = s[i]
+		i++
+		return v, true
+	})
+}
+
+// FromChannel creates a stream that receives from ch until it is closed
+func FromChannel[T any](ch <-chan T) Stream[T] {
+	return New(func() (T, bool) {
+		v, ok := <-ch
+		return v, ok
+	})
+}
+
+// FromScanner creates a stream of the tokens produced by sc (lines by default),
+// the scan error, if any, is reported by Err once the stream is exhausted
+func FromScanner(sc *bufio.Scanner) Stream[string] {
+	return Stream[string]{
+		next: func() (string, bool) {
+			if !sc.Scan() {
+				return "", false
+			}
+			return sc.Text(), true
+		},
+		err: sc.Err,
+	}
+}
+
+// Generate creates an infinite stream whose elements are produced by calling fn
+func Generate[T any](fn func() T) Stream[T] {
+	return New(func() (T, bool) {
+		return fn(), true
+	})
+}
+
+// Iterate creates an infinite stream of seed, fn(seed), fn(fn(seed)), ...
+func Iterate[T any](seed T, fn func(T) T) Stream[T] {
+	cur, started := seed, false
+	return New(func() (T, bool) {
+		if started {
+			cur = fn(cur)
+		}
+		started = true
+		return cur, true
+	})
+}
+
+// Next pulls the next element from the stream
+func (s Stream[T]) Next() (T, bool) {
+	if s.next == nil {
+		var zero T
+		return zero, false
+	}
+	return s.next()
+}
+
+// Err returns the error encountered by the underlying source, if any
+func (s Stream[T]) Err() error {
+	if s.err == nil {
+		return nil
+	}
+	return s.err()
+}
+
+// derive builds a stream that keeps the error source of s
+func derive[T, U any](s Stream[T], next func() (U, bool)) Stream[U] {
+	return Stream[U]{next: next, err: s.err}
+}
+
+// Filter keeps the elements for which fn returns true
+func (s Stream[T]) Filter(fn func(T) bool) Stream[T] {
+	return derive(s, func() (v T, ok bool) {
+		for {
+			if v, ok = s.Next(); !ok || fn(v) {
+				return
+			}
+		}
+	})
+}
+
+// Take limits the stream to its first n elements
+func (s Stream[T]) Take(n int) Stream[T] {
+	return derive(s, func() (v T, ok bool) {
+		if n <= 0 {
+			return
+		}
+		n--
+		return s.Next()
+	})
+}
+
+// TakeWhile yields elements as long as fn returns true
+func (s Stream[T]) TakeWhile(fn func(T) bool) Stream[T] {
+	done := false
+	return derive(s, func() (v T, ok bool) {
+		if done {
+			return
+		}
+		if v, ok = s.Next(); ok && fn(v) {
+			return
+		}
+		done = true
+		var zero T
+		return zero, false
+	})
+}
+
+// Skip drops the first n elements of the stream
+func (s Stream[T]) Skip(n int) Stream[T] {
+	return derive(s, func() (T, bool) {
+		for ; n > 0; n-- {
+			if _, ok := s.Next(); !ok {
+				break
+			}
+		}
+		return s.Next()
+	})
+}
+
+// SkipWhile drops elements as long as fn returns true
+func (s Stream[T]) SkipWhile(fn func(T) bool) Stream[T] {
+	skipping := true
+	return derive(s, func() (v T, ok bool) {
+		for {
+			if v, ok = s.Next(); !ok || !skipping || !fn(v) {
+				skipping = false
+				return
+			}
+		}
+	})
+}
+
+// Peek calls fn on each element as it flows through the stream
+func (s Stream[T]) Peek(fn func(T)) Stream[T] {
+	return derive(s, func() (v T, ok bool) {
+		if v, ok = s.Next(); ok {
+			fn(v)
+		}
+		return
+	})
+}
+
+// Collect consumes the stream into a slice
+func (s Stream[T]) Collect() []T {
+	var result []T
+	for v, ok := s.Next(); ok; v, ok = s.Next() {
+		result = append(result, v)
+	}
+	return result
+}
+
+// ForEach consumes the stream calling fn on every element
+func (s Stream[T]) ForEach(fn func(T)) {
+	for v, ok := s.Next(); ok; v, ok = s.Next() {
+		fn(v)
+	}
+}
+
+// ForEachUntil consumes the stream until fn returns false
+func (s Stream[T]) ForEachUntil(fn func(T) bool) {
+	for v, ok := s.Next(); ok; v, ok = s.Next() {
+		if !fn(v) {
+			return
+		}
+	}
+}
+
+// Count consumes the stream and returns the number of elements
+func (s Stream[T]) Count() int {
+	n := 0
+	for _, ok := s.Next(); ok; _, ok = s.Next() {
+		n++
+	}
+	return n
+}
+
+// First returns the first element of the stream
+func (s Stream[T]) First() (T, bool) {
+	return s.Next()
+}
+
+// AnyMatch reports whether any element satisfies fn, it stops at the first match
+func (s Stream[T]) AnyMatch(fn func(T) bool) bool {
+	_, ok := s.Filter(fn).Next()
+	return ok
+}
+
+// AllMatch reports whether all elements satisfy fn, it stops at the first mismatch
+func (s Stream[T]) AllMatch(fn func(T) bool) bool {
+	return !s.AnyMatch(func(v T) bool { return !fn(v) })
+}
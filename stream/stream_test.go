@@ -0,0 +1,136 @@
+package stream
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func expectInts(t *testing.T, got []int, want ...int) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestLazy(t *testing.T) {
+	pulled := 0
+	naturals := Iterate(0, func(v int) int { return v + 1 }).Peek(func(int) { pulled++ })
+
+	s := Map(naturals.Filter(func(v int) bool { return v%2 == 0 }), func(v int) int { return v * v })
+	if pulled != 0 {
+		t.Fatalf("building the stream pulled %d elements", pulled)
+	}
+	// 无限流在 Take 之后只计算需要的元素
+	expectInts(t, s.Take(3).Collect(), 0, 4, 16)
+	if pulled != 5 {
+		t.Fatalf("pulled %d elements for the first 3 even squares, want 5", pulled)
+	}
+
+	calls := 0
+	first, ok := Generate(func() int { calls++; return calls }).Skip(2).First()
+	if !ok || first != 3 || calls != 3 {
+		t.Fatalf("First: got %d %v after %d calls", first, ok, calls)
+	}
+	if !Iterate(1, func(v int) int { return v * 2 }).AnyMatch(func(v int) bool { return v > 1000 }) {
+		t.Fatal("AnyMatch did not stop on an infinite stream")
+	}
+}
+
+func TestOperations(t *testing.T) {
+	s := Of(5, 1, 5, 2, 8, 1, 3)
+	expectInts(t, Distinct(s).Collect(), 5, 1, 2, 8, 3)
+
+	expectInts(t, Of(1, 2, 3, 4, 1).TakeWhile(func(v int) bool { return v < 3 }).Collect(), 1, 2)
+	expectInts(t, Of(1, 2, 3, 4, 1).SkipWhile(func(v int) bool { return v < 3 }).Collect(), 3, 4, 1)
+	expectInts(t, FlatMap(Of(1, 0, 2), func(v int) []int { return make([]int, v) }).Collect(), 0, 0, 0)
+	expectInts(t, Concat(Of(1), Empty[int](), Of(2, 3)).Collect(), 1, 2, 3)
+
+	sum := Reduce(Of(1, 2, 3), "", func(acc string, v int) string { return acc + fmt.Sprint(v) })
+	if sum != "123" {
+		t.Fatalf("Reduce: got %q, want 123", sum)
+	}
+	if Of(2, 4).AllMatch(func(v int) bool { return v%2 == 0 }) != true || Of(1, 2).Count() != 2 {
+		t.Fatal("AllMatch or Count returned a wrong result")
+	}
+
+	var chunks [][]int
+	Chunk(Of(1, 2, 3, 4, 5), 2).ForEach(func(c []int) { chunks = append(chunks, c) })
+	if len(chunks) != 3 || len(chunks[2]) != 1 || chunks[2][0] != 5 {
+		t.Fatalf("Chunk: got %v", chunks)
+	}
+}
+
+func TestWindow(t *testing.T) {
+	tests := []struct {
+		size, step int
+		want       [][]int
+	}{
+		{3, 1, [][]int{{1, 2, 3}, {2, 3, 4}, {3, 4, 5}, {4, 5, 6}, {5, 6, 7}}},
+		{3, 3, [][]int{{1, 2, 3}, {4, 5, 6}}},
+		// 步长大于窗口时跳过中间的元素
+		{2, 3, [][]int{{1, 2}, {4, 5}}},
+		{1, 4, [][]int{{1}, {5}}},
+		{8, 1, nil},
+		{0, 1, nil},
+		{2, 0, nil},
+	}
+	for _, tt := range tests {
+		got := Window(Of(1, 2, 3, 4, 5, 6, 7), tt.size, tt.step).Collect()
+		if len(got) != len(tt.want) {
+			t.Fatalf("Window(%d, %d): got %v, want %v", tt.size, tt.step, got, tt.want)
+		}
+		for i := range got {
+			expectInts(t, got[i], tt.want[i]...)
+		}
+	}
+}
+
+func TestChannels(t *testing.T) {
+	done := make(chan struct{})
+	ch := ToChannel(Iterate(0, func(v int) int { return v + 1 }), done)
+	expectInts(t, FromChannel(ch).Take(3).Collect(), 0, 1, 2)
+	close(done)
+	// done 关闭后 channel 被关闭，最多还能收到一个已经在发送的元素
+	n := 0
+	for range ch {
+		n++
+	}
+	if n > 1 {
+		t.Fatalf("received %d elements after done was closed", n)
+	}
+}
+
+type failingReader struct{ data string }
+
+var errRead = errors.New("read failed")
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if r.data == "" {
+		return 0, errRead
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+func TestFromScannerErr(t *testing.T) {
+	s := FromScanner(bufio.NewScanner(strings.NewReader("a\nb\n")))
+	if got := s.Collect(); len(got) != 2 || got[1] != "b" || s.Err() != nil {
+		t.Fatalf("Collect: got %v %v", got, s.Err())
+	}
+
+	s = Map(FromScanner(bufio.NewScanner(&failingReader{data: "a\nb"})), strings.ToUpper)
+	got := s.Collect()
+	// 错误会传递到派生的流
+	if len(got) != 2 || got[0] != "A" || !errors.Is(s.Err(), errRead) {
+		t.Fatalf("Collect: got %v %v, want [A B] and the read error", got, s.Err())
+	}
+}
@@ -2,6 +2,7 @@ package impl
 
 import (
 	"errors"
+	"github.com/DavidYou21st/go-tools/queue"
 	"github.com/gorilla/websocket"
 	"sync"
 	"time"
@@ -14,6 +15,11 @@ type Connection struct {
 	closeChannel chan byte
 	isClose      bool
 	mutex        sync.Mutex
+
+	// 基于时间轮的心跳和空闲超时定时器，连接关闭时取消
+	heartbeatTimer *queue.WheelTimer
+	idleTimer      *queue.WheelTimer
+	idleTimeout    time.Duration
 }
 
 func InitConnection(wsConn *websocket.Conn) (conn *Connection, err error) {
@@ -55,6 +61,12 @@ func (conn *Connection) Close() {
 	if !conn.isClose {
 		close(conn.closeChannel)
 		conn.isClose = true
+		if conn.heartbeatTimer != nil {
+			conn.heartbeatTimer.Stop()
+		}
+		if conn.idleTimer != nil {
+			conn.idleTimer.Stop()
+		}
 	}
 	conn.mutex.Unlock()
 }
@@ -70,6 +82,7 @@ func (conn *Connection) readLoop() {
 		if _, data, err = conn.wsConn.ReadMessage(); err != nil {
 			goto ERR
 		}
+		conn.touch()
 
 		//阻塞在这里，等待inChan有空闲位置
 		select {
@@ -108,3 +121,47 @@ func (conn *Connection) Heartbeat() {
 		time.Sleep(5 * time.Second)
 	}
 }
+
+// HeartbeatWithWheel 使用时间轮定时发送心跳，大量连接共享同一个时间轮，避免每个连接占用一个协程和定时器
+// 连接关闭时取消尚未执行的心跳
+func (conn *Connection) HeartbeatWithWheel(tw *queue.TimingWheel, interval time.Duration) {
+	if err := conn.WriteMessage([]byte("heartbeat")); err != nil {
+		return
+	}
+
+	conn.mutex.Lock()
+	defer conn.mutex.Unlock()
+	if conn.isClose {
+		return
+	}
+	if conn.heartbeatTimer != nil {
+		conn.heartbeatTimer.Stop()
+	}
+	conn.heartbeatTimer = tw.AfterFunc(interval, func() {
+		conn.HeartbeatWithWheel(tw, interval)
+	})
+}
+
+// IdleTimeoutWithWheel 使用时间轮检测空闲连接，超过 timeout 没有收到消息时关闭连接
+// 每次收到消息都会重新计时
+func (conn *Connection) IdleTimeoutWithWheel(tw *queue.TimingWheel, timeout time.Duration) {
+	conn.mutex.Lock()
+	defer conn.mutex.Unlock()
+	if conn.isClose {
+		return
+	}
+	if conn.idleTimer != nil {
+		conn.idleTimer.Stop()
+	}
+	conn.idleTimeout = timeout
+	conn.idleTimer = tw.AfterFunc(timeout, conn.Close)
+}
+
+// touch 收到消息后重置空闲超时
+func (conn *Connection) touch() {
+	conn.mutex.Lock()
+	defer conn.mutex.Unlock()
+	if conn.idleTimer != nil && !conn.isClose {
+		conn.idleTimer.Reset(conn.idleTimeout)
+	}
+}
@@ -0,0 +1,98 @@
+package impl
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DavidYou21st/go-tools/queue"
+	"github.com/gorilla/websocket"
+)
+
+// dial 启动一个 websocket 服务端，返回服务端的 Connection 和客户端连接
+func dial(t *testing.T) (*Connection, *websocket.Conn) {
+	t.Helper()
+	conns := make(chan *Connection, 1)
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		wsConn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		conn, _ := InitConnection(wsConn)
+		conns <- conn
+	}))
+	t.Cleanup(server.Close)
+
+	client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return <-conns, client
+}
+
+func TestHeartbeatWithWheel(t *testing.T) {
+	tw := queue.NewTimingWheel(time.Millisecond, 64)
+	tw.Start()
+	defer tw.Stop()
+
+	conn, client := dial(t)
+	conn.HeartbeatWithWheel(tw, 10*time.Millisecond)
+	for i := 0; i < 3; i++ {
+		_ = client.SetReadDeadline(time.Now().Add(5 * time.Second))
+		_, data, err := client.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != "heartbeat" {
+			t.Fatalf("got %q, want heartbeat", data)
+		}
+	}
+
+	conn.Close()
+	if n := tw.Len(); n != 0 {
+		t.Fatalf("%d timers still pending after Close", n)
+	}
+}
+
+func TestIdleTimeoutWithWheel(t *testing.T) {
+	tw := queue.NewTimingWheel(time.Millisecond, 64)
+	tw.Start()
+	defer tw.Stop()
+
+	conn, client := dial(t)
+	conn.IdleTimeoutWithWheel(tw, 50*time.Millisecond)
+
+	// 持续发送消息时连接保持打开
+	for i := 0; i < 5; i++ {
+		if err := client.WriteMessage(websocket.TextMessage, []byte("ping")); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := conn.ReadMessage(); err != nil {
+			t.Fatalf("connection closed while active: %v", err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	// 停止发送后连接因空闲超时被关闭
+	closed := make(chan error, 1)
+	go func() {
+		_, err := conn.ReadMessage()
+		closed <- err
+	}()
+	select {
+	case err := <-closed:
+		if err == nil {
+			t.Fatal("ReadMessage returned a message on an idle connection")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("idle connection was not closed")
+	}
+	if n := tw.Len(); n != 0 {
+		t.Fatalf("%d timers still pending after the idle timeout", n)
+	}
+}